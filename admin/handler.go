@@ -0,0 +1,374 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"slices"
+	"time"
+
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/zap"
+)
+
+// Ingester is the behavior the admin API requires of an ingester: it must report the
+// outcome of its most recent ingest.
+type Ingester interface {
+	// Status returns the outcome of the most recent ingest.
+	Status() service.IngestStatus
+}
+
+// Endpoint is the JSON representation of a single endpoint.
+type Endpoint struct {
+	Name       string             `json:"name"`
+	Priority   uint16             `json:"priority"`
+	Weight     uint16             `json:"weight"`
+	Port       uint16             `json:"port"`
+	Addresses  []netip.Addr       `json:"addresses"`
+	Attributes service.Attributes `json:"attributes,omitempty"`
+	Draining   bool               `json:"draining"`
+}
+
+// Group is the JSON representation of a single group.
+type Group struct {
+	Name       string             `json:"name"`
+	Services   []string           `json:"services"`
+	Attributes service.Attributes `json:"attributes,omitempty"`
+	Endpoints  []Endpoint         `json:"endpoints"`
+}
+
+// LocatedEndpoint is the JSON representation of an endpoint found for an object.
+type LocatedEndpoint struct {
+	Endpoint
+	Rank int `json:"rank"`
+}
+
+// Location is the JSON representation of where an object is placed within a single group.
+type Location struct {
+	Group string `json:"group"`
+
+	// Endpoints are where the object hashes to, including draining endpoints.
+	Endpoints []LocatedEndpoint `json:"endpoints"`
+
+	// Assigned are the endpoints DNS answers hand out for the object, which skip draining endpoints.
+	Assigned []LocatedEndpoint `json:"assigned"`
+}
+
+// FileError is the JSON representation of a zone file that could not be ingested.
+type FileError struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// SourceStatus is the JSON representation of the health of a single source of groups.
+type SourceStatus struct {
+	Name        string      `json:"name"`
+	Time        time.Time   `json:"time"`
+	LastSuccess *time.Time  `json:"lastSuccess,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	RRs         int         `json:"rrs"`
+	FileErrors  []FileError `json:"fileErrors,omitempty"`
+}
+
+// IngestStatus is the JSON representation of the outcome of the most recent ingest.
+type IngestStatus struct {
+	Checksum   uint32         `json:"checksum"`
+	Time       time.Time      `json:"time"`
+	Error      string         `json:"error,omitempty"`
+	FileErrors []FileError    `json:"fileErrors,omitempty"`
+	Sources    []SourceStatus `json:"sources,omitempty"`
+}
+
+// Error is the JSON representation of a failed admin request.
+type Error struct {
+	Error string `json:"error"`
+}
+
+type HandlerOption interface {
+	applyToHandler(*Handler) error
+}
+
+type handlerOptionFunc func(*Handler) error
+
+func (f handlerOptionFunc) applyToHandler(h *Handler) error { return f(h) }
+
+func WithLogger(base *zap.Logger) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		h.logger = base.Named("admin")
+		return nil
+	})
+}
+
+func WithLocator(l *service.Locator) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.locator = l
+		return nil
+	})
+}
+
+func WithIngester(i Ingester) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.ingester = i
+		return nil
+	})
+}
+
+// WithTrigger sets what POST /ingest triggers. This option is required.
+func WithTrigger(t service.IngestTrigger) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.trigger = t
+		return nil
+	})
+}
+
+// Handler is the admin API. All responses are JSON. The routes are:
+//
+//	GET    /groups                      lists all groups, with their services and endpoints
+//	GET    /groups/{group}              describes a single group
+//	GET    /locate/{object}             locates an object in each group, optionally filtered by ?group=
+//	GET    /ingest                      shows the outcome of the most recent ingest
+//	POST   /ingest                      triggers an immediate ingest, without waiting for it
+//	PUT    /endpoints/{endpoint}/drain  drains an endpoint, by original name
+//	DELETE /endpoints/{endpoint}/drain  stops draining an endpoint
+//
+// The admin API has no authentication of its own, so it must only be reachable by operators,
+// e.g. by listening on loopback.
+type Handler struct {
+	logger   *zap.Logger
+	locator  *service.Locator
+	ingester Ingester
+	trigger  service.IngestTrigger
+	mux      *http.ServeMux
+}
+
+// NewHandler creates an admin Handler. A Locator, an Ingester, and a trigger are required.
+func NewHandler(opts ...HandlerOption) (*Handler, error) {
+	h := new(Handler)
+	for _, o := range opts {
+		if err := o.applyToHandler(h); err != nil {
+			return nil, err
+		}
+	}
+
+	if h.logger == nil {
+		h.logger = zap.NewNop()
+	}
+
+	if h.locator == nil {
+		return nil, errors.New("a locator is required")
+	}
+
+	if h.ingester == nil {
+		return nil, errors.New("an ingester is required")
+	}
+
+	if h.trigger == nil {
+		return nil, errors.New("an ingest trigger is required")
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("GET /groups", h.getGroups)
+	h.mux.HandleFunc("GET /groups/{group}", h.getGroup)
+	h.mux.HandleFunc("GET /locate/{object}", h.locate)
+	h.mux.HandleFunc("GET /ingest", h.getIngest)
+	h.mux.HandleFunc("POST /ingest", h.postIngest)
+	h.mux.HandleFunc("PUT /endpoints/{endpoint}/drain", h.drain)
+	h.mux.HandleFunc("DELETE /endpoints/{endpoint}/drain", h.drain)
+
+	return h, nil
+}
+
+// Handle adds another route to this Handler, which allows other components to
+// share the admin server.
+func (h *Handler) Handle(pattern string, handler http.Handler) {
+	h.mux.Handle(pattern, handler)
+}
+
+func (h *Handler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	h.mux.ServeHTTP(response, request)
+}
+
+// writeJSON writes a value as the JSON body of a response.
+func (h *Handler) writeJSON(response http.ResponseWriter, status int, v any) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+	if err := json.NewEncoder(response).Encode(v); err != nil {
+		h.logger.Error("unable to write response", zap.Error(err))
+	}
+}
+
+func (h *Handler) newEndpoint(e *service.Endpoint) Endpoint {
+	return Endpoint{
+		Name:       e.OriginalName(),
+		Priority:   e.Priority(),
+		Weight:     e.Weight(),
+		Port:       e.Port(),
+		Addresses:  slices.Collect(e.Addrs()),
+		Attributes: e.Attributes(),
+		Draining:   h.locator.Draining(e),
+	}
+}
+
+func (h *Handler) newGroup(g *service.Group) Group {
+	group := Group{
+		Name:       g.Name(),
+		Services:   slices.Collect(g.Services()),
+		Attributes: g.Attributes(),
+		Endpoints:  make([]Endpoint, 0, g.Len()),
+	}
+
+	for e := range g.Endpoints() {
+		group.Endpoints = append(group.Endpoints, h.newEndpoint(e))
+	}
+
+	return group
+}
+
+func (h *Handler) newLocatedEndpoints(les service.LocatedEndpoints) []LocatedEndpoint {
+	located := make([]LocatedEndpoint, 0, len(les))
+	for _, le := range les {
+		located = append(located, LocatedEndpoint{
+			Endpoint: h.newEndpoint(le.Endpoint),
+			Rank:     le.Rank,
+		})
+	}
+
+	return located
+}
+
+func (h *Handler) getGroups(response http.ResponseWriter, _ *http.Request) {
+	groups := []Group{}
+	if gps := h.locator.Groups(); gps != nil {
+		for g := range gps.All() {
+			groups = append(groups, h.newGroup(g))
+		}
+	}
+
+	h.writeJSON(response, http.StatusOK, groups)
+}
+
+func (h *Handler) getGroup(response http.ResponseWriter, request *http.Request) {
+	name := request.PathValue("group")
+	if gps := h.locator.Groups(); gps != nil {
+		if g := gps.Get(name); g != nil {
+			h.writeJSON(response, http.StatusOK, h.newGroup(g))
+			return
+		}
+	}
+
+	h.writeJSON(response, http.StatusNotFound, Error{Error: "no such group: " + name})
+}
+
+func (h *Handler) locate(response http.ResponseWriter, request *http.Request) {
+	var (
+		object = request.PathValue("object")
+		names  = request.URL.Query()["group"]
+		gps    = h.locator.Groups()
+	)
+
+	if len(names) == 0 && gps != nil {
+		for g := range gps.All() {
+			names = append(names, g.Name())
+		}
+	}
+
+	locations := make([]Location, 0, len(names))
+	for _, name := range names {
+		if gps == nil || gps.Get(name) == nil {
+			h.writeJSON(response, http.StatusNotFound, Error{Error: "no such group: " + name})
+			return
+		}
+
+		locations = append(locations, Location{
+			Group:     name,
+			Endpoints: h.newLocatedEndpoints(h.locator.FindString(object, name)),
+			Assigned:  h.newLocatedEndpoints(h.locator.AssignString(object, name)),
+		})
+	}
+
+	h.writeJSON(response, http.StatusOK, locations)
+}
+
+// newFileErrors converts zone file errors into their JSON representation.
+func newFileErrors(fes []service.FileError) (converted []FileError) {
+	for _, fe := range fes {
+		converted = append(converted, FileError{
+			Path:    fe.Path,
+			Line:    fe.Line,
+			Message: fe.Message,
+		})
+	}
+
+	return
+}
+
+func (h *Handler) writeIngestStatus(response http.ResponseWriter, httpStatus int) {
+	status := h.ingester.Status()
+	body := IngestStatus{
+		Checksum: status.Checksum,
+		Time:     status.Time,
+	}
+
+	if status.Err != nil {
+		body.Error = status.Err.Error()
+	}
+
+	body.FileErrors = newFileErrors(status.FileErrors)
+	for _, ss := range status.Sources {
+		source := SourceStatus{
+			Name:       ss.Name,
+			Time:       ss.Time,
+			RRs:        ss.RRs,
+			FileErrors: newFileErrors(ss.FileErrors),
+		}
+
+		if !ss.LastSuccess.IsZero() {
+			source.LastSuccess = &ss.LastSuccess
+		}
+
+		if ss.Err != nil {
+			source.Error = ss.Err.Error()
+		}
+
+		body.Sources = append(body.Sources, source)
+	}
+
+	h.writeJSON(response, httpStatus, body)
+}
+
+func (h *Handler) getIngest(response http.ResponseWriter, _ *http.Request) {
+	h.writeIngestStatus(response, http.StatusOK)
+}
+
+// postIngest triggers an ingest and responds with the outcome of the previous one. The
+// ingest runs apart from the request, so a client that disconnects cannot cancel it.
+func (h *Handler) postIngest(response http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("ingest requested")
+	h.trigger.Trigger()
+	h.writeIngestStatus(response, http.StatusAccepted)
+}
+
+func (h *Handler) drain(response http.ResponseWriter, request *http.Request) {
+	var (
+		name  = dnsutil.Fqdn(request.PathValue("endpoint"))
+		drain = request.Method == http.MethodPut
+	)
+
+	h.logger.Info("drain requested", zap.String("originalName", name), zap.Bool("drain", drain))
+	if !h.locator.Drain(name, drain) {
+		h.writeJSON(response, http.StatusNotFound, Error{Error: "no such endpoint: " + name})
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
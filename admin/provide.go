@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"net"
+	"net/netip"
+
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/hashyhttp"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Server is the HTTP server for the admin API.
+type Server struct {
+	*hashyhttp.Server
+}
+
+// isLoopback tests if a listen address only accepts connections from this host.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	} else if host == "localhost" {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
+
+// Provide builds the admin API. The admin server is only started when an address is configured.
+func Provide() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			func(base *zap.Logger, loc *service.Locator, si service.StatusIngester, ic *service.IngestChecker) (*Handler, error) {
+				return NewHandler(
+					WithLogger(base),
+					WithLocator(loc),
+					WithIngester(si),
+					WithTrigger(ic),
+				)
+			},
+			func(acfg config.Admin, h *Handler, base *zap.Logger, lc fx.Lifecycle, sh fx.Shutdowner) (s Server) {
+				if len(acfg.Address) > 0 {
+					if !isLoopback(acfg.Address) {
+						base.Warn("the admin API is unauthenticated and not limited to loopback", zap.String("address", acfg.Address))
+					}
+
+					s.Server = hashyhttp.NewServer("admin", acfg.HTTPServer, h, base)
+					s.BindToLifecycle(lc, sh)
+				}
+
+				return
+			},
+		),
+		fx.Invoke(
+			// ensure that the admin server is always created
+			func(Server) {},
+		),
+	)
+}
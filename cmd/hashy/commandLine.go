@@ -11,17 +11,27 @@ import (
 
 	"github.com/alecthomas/kong"
 	"github.com/spf13/viper"
+	"github.com/xmidt-org/hashy/admin"
 	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/metrics"
+	"github.com/xmidt-org/hashy/probes"
 	"github.com/xmidt-org/hashy/server"
 	"github.com/xmidt-org/hashy/service"
+	"github.com/xmidt-org/hashy/tracing"
 	"github.com/xmidt-org/sallust"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 )
 
-// CommandLine represents the hashy command line.
+// CommandLine represents the hashy command line. Serving is the default command.
 type CommandLine struct {
+	Serve   ServeCommand   `cmd:"" default:"withargs" help:"runs the hashy server"`
+	Convert ConvertCommand `cmd:"" help:"converts groups between zone files and YAML or JSON group documents"`
+}
+
+// ServeCommand runs the hashy server.
+type ServeCommand struct {
 	Verbose       bool          `help:"turns on verbose logging, which includes the fx.App startup logs"`
 	Debug         *bool         `help:"turns on debugging, overriding configuration"`
 	ConfFile      string        `name:"conf-file" help:"configuration file to read. If unset, /etc/hashy, $HOME/.hashy, and the current directory will be searched for hashy.yaml"`
@@ -29,10 +39,10 @@ type CommandLine struct {
 	CheckInterval time.Duration `name:"check-interval" help:"the interval on which hashy reingests DNS RRs from its external sources. Overrides configuration."`
 }
 
-func (cl *CommandLine) newViper() (v *viper.Viper, err error) {
+func (sc *ServeCommand) newViper() (v *viper.Viper, err error) {
 	v = viper.New()
-	if len(cl.ConfFile) > 0 {
-		v.SetConfigFile(cl.ConfFile)
+	if len(sc.ConfFile) > 0 {
+		v.SetConfigFile(sc.ConfFile)
 	} else {
 		v.SetConfigType("yaml")
 		v.SetConfigName("hashy")
@@ -41,7 +51,7 @@ func (cl *CommandLine) newViper() (v *viper.Viper, err error) {
 		v.AddConfigPath("/etc/hashy")
 	}
 
-	if err = v.ReadInConfig(); err == nil || len(cl.ConfFile) > 0 {
+	if err = v.ReadInConfig(); err == nil || len(sc.ConfFile) > 0 {
 		return
 	}
 
@@ -57,22 +67,22 @@ func (cl *CommandLine) newViper() (v *viper.Viper, err error) {
 
 // decorateGroups adds the command-line zone files, if any, to the ZoneFiles configuration
 // value. Additionally, all ZoneFiles are expanded.
-func (cl *CommandLine) decorateGroups(l *zap.Logger, gcfg config.Groups) config.Groups {
-	gcfg.ZoneFiles = slices.Grow(gcfg.ZoneFiles, len(cl.ZoneFiles))
-	gcfg.ZoneFiles = append(gcfg.ZoneFiles, cl.ZoneFiles...)
+func (sc *ServeCommand) decorateGroups(l *zap.Logger, gcfg config.Groups) config.Groups {
+	gcfg.ZoneFiles = slices.Grow(gcfg.ZoneFiles, len(sc.ZoneFiles))
+	gcfg.ZoneFiles = append(gcfg.ZoneFiles, sc.ZoneFiles...)
 
-	if cl.CheckInterval > 0 {
-		gcfg.CheckInterval = cl.CheckInterval
+	if sc.CheckInterval > 0 {
+		gcfg.CheckInterval = sc.CheckInterval
 	}
 
 	return gcfg
 }
 
-func (cl *CommandLine) decorateSallust(cfg sallust.Config) sallust.Config {
+func (sc *ServeCommand) decorateSallust(cfg sallust.Config) sallust.Config {
 	switch {
-	case cl.Debug != nil && *cl.Debug:
+	case sc.Debug != nil && *sc.Debug:
 		cfg.Level = zap.DebugLevel.String()
-	case cl.Debug != nil && !*cl.Debug:
+	case sc.Debug != nil && !*sc.Debug:
 		cfg.Level = zap.InfoLevel.String()
 	}
 
@@ -81,8 +91,8 @@ func (cl *CommandLine) decorateSallust(cfg sallust.Config) sallust.Config {
 
 // AfterApply sets up bindings for Run. Messages from these components are much easier to
 // read and debug when done outside an fx.App.
-func (cl *CommandLine) AfterApply(ctx *kong.Context) (err error) {
-	v, err := cl.newViper()
+func (sc *ServeCommand) AfterApply(ctx *kong.Context) (err error) {
+	v, err := sc.newViper()
 	if err == nil {
 		ctx.Bind(v)
 	}
@@ -90,7 +100,7 @@ func (cl *CommandLine) AfterApply(ctx *kong.Context) (err error) {
 	return
 }
 
-func (cl *CommandLine) provideLogging() fx.Option {
+func (sc *ServeCommand) provideLogging() fx.Option {
 	return fx.Options(
 		fx.Provide(
 			func(cfg sallust.Config) (*zap.Logger, error) {
@@ -99,7 +109,7 @@ func (cl *CommandLine) provideLogging() fx.Option {
 		),
 		fx.WithLogger(
 			func(l *zap.Logger) fxevent.Logger {
-				if cl.Verbose {
+				if sc.Verbose {
 					return &fxevent.ZapLogger{
 						Logger: l,
 					}
@@ -114,16 +124,20 @@ func (cl *CommandLine) provideLogging() fx.Option {
 }
 
 // Run executes the hashy server.
-func (cl *CommandLine) Run(v *viper.Viper) error {
+func (sc *ServeCommand) Run(v *viper.Viper) error {
 	app := fx.New(
 		fx.Supply(v),
-		cl.provideLogging(),
+		sc.provideLogging(),
 		config.Provide(),
+		metrics.Provide(),
+		tracing.Provide(),
 		service.Provide(),
 		server.Provide(),
+		admin.Provide(),
+		probes.Provide(),
 		fx.Decorate(
-			cl.decorateGroups,
-			cl.decorateSallust,
+			sc.decorateGroups,
+			sc.decorateSallust,
 		),
 		fx.Invoke(
 			func(v *viper.Viper, l *zap.Logger) {
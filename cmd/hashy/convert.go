@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"github.com/alecthomas/kong"
+	"github.com/xmidt-org/hashy"
+	"github.com/xmidt-org/hashy/service"
+	"github.com/xmidt-org/medley"
+	"go.yaml.in/yaml/v3"
+)
+
+// isDocument tests if a path names a YAML or JSON group document rather than a zone file.
+func isDocument(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+
+	default:
+		return false
+	}
+}
+
+// ConvertCommand translates groups between zone files and group documents. Inputs may be
+// any mix of zone files and documents, and the output holds the groups of all of them.
+type ConvertCommand struct {
+	Files           []string      `arg:"" help:"zone files or group documents to convert. Files ending in .yaml, .yml, or .json are documents. Globs are allowed."`
+	To              string        `name:"to" enum:"zone,yaml,json" default:"yaml" help:"the output format: zone, yaml, or json"`
+	Output          string        `name:"output" short:"o" help:"the file to write. If unset, output goes to stdout."`
+	DiscoveryDomain string        `name:"discovery-domain" default:"_hashy.discover" help:"the domain that holds group definitions"`
+	Origin          string        `name:"origin" help:"the origin to use when parsing zone files"`
+	TTL             time.Duration `name:"ttl" default:"5m" help:"the TTL of zone file records that have none, and of generated records"`
+}
+
+// readRRs reads the records from every input, converting documents into records.
+func (cc *ConvertCommand) readRRs() (rrs []dns.RR, err error) {
+	var zoneFiles, documents []string
+	for _, f := range cc.Files {
+		if isDocument(f) {
+			documents = append(documents, f)
+		} else {
+			zoneFiles = append(zoneFiles, f)
+		}
+	}
+
+	var sources []service.RRSource
+	if len(zoneFiles) > 0 {
+		var fi *service.FileIngester
+		fi, err = service.NewFileIngester(
+			service.WithGlobs(zoneFiles...),
+			service.WithDiscoveryDomain(cc.DiscoveryDomain),
+			service.WithOrigin(cc.Origin),
+			service.WithTTL(cc.TTL),
+		)
+
+		if err != nil {
+			return
+		}
+
+		sources = append(sources, fi)
+	}
+
+	if len(documents) > 0 {
+		var di *service.DocumentIngester
+		di, err = service.NewDocumentIngester(
+			service.WithDocuments(documents...),
+			service.WithDocumentDiscoveryDomain(cc.DiscoveryDomain),
+			service.WithDocumentTTL(cc.TTL),
+		)
+
+		if err != nil {
+			return
+		}
+
+		sources = append(sources, di)
+	}
+
+	checksummer := medley.AsConstructor32(adler32.New)()
+	for _, s := range sources {
+		var src service.SourceRRs
+		if src, err = s.ReadRRs(context.Background(), checksummer); err != nil {
+			return
+		}
+
+		rrs = append(rrs, src.RRs...)
+	}
+
+	return
+}
+
+// write writes the groups in the requested format.
+func (cc *ConvertCommand) write(w io.Writer, doc service.GroupsDocument) (err error) {
+	switch cc.To {
+	case "zone":
+		var rrs []dns.RR
+		if rrs, err = doc.RRs(cc.DiscoveryDomain, hashy.DurationToSeconds(cc.TTL)); err != nil {
+			return
+		}
+
+		for _, rr := range rrs {
+			if _, err = fmt.Fprintln(w, rr.String()); err != nil {
+				return
+			}
+		}
+
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(doc)
+
+	default:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err = encoder.Encode(doc); err == nil {
+			err = encoder.Close()
+		}
+	}
+
+	return
+}
+
+// Run converts the input files.
+func (cc *ConvertCommand) Run(ctx *kong.Context) error {
+	rrs, err := cc.readRRs()
+	if err != nil {
+		return err
+	}
+
+	doc, err := service.NewGroupsDocument(rrs, cc.DiscoveryDomain)
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	if err := cc.write(&output, doc); err != nil {
+		return err
+	}
+
+	if len(cc.Output) > 0 {
+		return os.WriteFile(cc.Output, output.Bytes(), 0644)
+	}
+
+	_, err = output.WriteTo(ctx.Stdout)
+	return err
+}
@@ -15,11 +15,27 @@ import (
 //go:embed defaultConfig.yaml
 var Default string
 
-// Zone describes the zone that hashy serves.
+// Zone describes a zone that hashy serves.
 type Zone struct {
 	// Domain is the domain that hash serves. If unset, this defaults to DefaultDomain.
 	Domain string `json:"domain" yaml:"domain" mapstructure:"domain"`
 
+	// EndpointLabel is the subdomain of Domain that serves endpoint hashes. If unset,
+	// this defaults to "endpoint".
+	EndpointLabel string `json:"endpointLabel" yaml:"endpointLabel" mapstructure:"endpointLabel"`
+
+	// GroupLabel is the subdomain of Domain that serves group metadata. If unset,
+	// this defaults to "group".
+	GroupLabel string `json:"groupLabel" yaml:"groupLabel" mapstructure:"groupLabel"`
+
+	// ExplainLabel is the subdomain of Domain that explains how objects were placed. If unset,
+	// this defaults to "explain".
+	ExplainLabel string `json:"explainLabel" yaml:"explainLabel" mapstructure:"explainLabel"`
+
+	// ExplainACL is the list of CIDR prefixes or addresses of clients allowed to make explain
+	// requests. If unset, all explain requests are refused.
+	ExplainACL []string `json:"explainACL" yaml:"explainACL" mapstructure:"explainACL"`
+
 	// TTL is the base time-to-live of records generated in this zone.
 	TTL time.Duration `json:"ttl" yaml:"ttl" mapstructure:"ttl"`
 
@@ -30,6 +46,9 @@ type Zone struct {
 	TTLJitter float32 `json:"ttlJitter" yaml:"ttlJitter" mapstructure:"ttlJitter"`
 }
 
+// Zones is the list of zones that hashy serves. Each zone must have a distinct domain.
+type Zones []Zone
+
 // UDP is the configuration for a single UDP server that serve DNS traffic.
 type UDP struct {
 	Address     string        `json:"address" yaml:"address" mapstructure:"address"`
@@ -56,16 +75,158 @@ type TCP struct {
 
 type TCPServers map[string]TCP
 
+// Update configures dynamic updates to groups via RFC 2136 DNS UPDATE messages.
+type Update struct {
+	// Zones are the zones that may be updated, e.g. the origin of the zone files. Every
+	// updated name must be within the zone named by the update. If unset, all updates are refused.
+	Zones []string `json:"zones" yaml:"zones" mapstructure:"zones"`
+
+	// Keys maps TSIG key names onto their base64 encoded secrets. Every update must be
+	// signed by one of these keys.
+	Keys map[string]string `json:"keys" yaml:"keys" mapstructure:"keys"`
+
+	// ACL is the list of CIDR prefixes or addresses of clients allowed to send updates.
+	// If unset, signed updates are accepted from any client.
+	ACL []string `json:"acl" yaml:"acl" mapstructure:"acl"`
+
+	// Journal is the file updates are persisted to, so that they survive restarts.
+	// If unset, updates are only held in memory.
+	Journal string `json:"journal" yaml:"journal" mapstructure:"journal"`
+}
+
+// Notify configures RFC 1996 DNS NOTIFY messages, which trigger an immediate ingest when
+// a source zone changes.
+type Notify struct {
+	// Zones restricts the source zones that NOTIFY messages may name. If unset, any zone
+	// pulled by a configured zone transfer may be named.
+	Zones []string `json:"zones" yaml:"zones" mapstructure:"zones"`
+
+	// ACL is the list of CIDR prefixes or addresses of the primaries allowed to send NOTIFY
+	// messages. If unset, NOTIFY messages are refused.
+	ACL []string `json:"acl" yaml:"acl" mapstructure:"acl"`
+}
+
 // DNS is the configuration all all servers that serve DNS traffic.
 type DNS struct {
-	// Zone holds information about the synthetic zone that hashy serves.
+	// Zone holds information about the synthetic zone that hashy serves. This field
+	// is only used when Zones is empty.
 	Zone Zone `json:"zone" yaml:"zone" mapstructure:"zone"`
 
+	// Zones holds all the synthetic zones that hashy serves. This allows hashy to
+	// serve several domains at once, e.g. during a domain migration.
+	Zones Zones `json:"zones" yaml:"zones" mapstructure:"zones"`
+
 	// UDP holds all the UDP servers for DNS. The keys in the map are human-friendly server names.
 	UDP UDPServers `json:"udp" yaml:"udp" mapstructure:"udp"`
 
 	// TCP holds all the TCP servers for DNS. The keys in the map are human-friendly server names.
 	TCP TCPServers `json:"tcp" yaml:"tcp" mapstructure:"tcp"`
+
+	// Update configures dynamic updates to groups. Updates are disabled by default.
+	Update Update `json:"update" yaml:"update" mapstructure:"update"`
+
+	// Notify configures NOTIFY messages from primaries. NOTIFY messages are refused by default.
+	Notify Notify `json:"notify" yaml:"notify" mapstructure:"notify"`
+}
+
+// Transfer configures a zone that is pulled from a primary DNS server, first with AXFR
+// and then with IXFR.
+type Transfer struct {
+	// Zone is the name of the zone to transfer.
+	Zone string `json:"zone" yaml:"zone" mapstructure:"zone"`
+
+	// Primary is the address of the primary server, as host:port. If no port is given, 53 is used.
+	Primary string `json:"primary" yaml:"primary" mapstructure:"primary"`
+
+	// Key is the name of the TSIG key used to sign transfer requests. If unset, transfers are not signed.
+	Key string `json:"key" yaml:"key" mapstructure:"key"`
+
+	// Secret is the base64 encoded secret of the TSIG key.
+	Secret string `json:"secret" yaml:"secret" mapstructure:"secret"`
+
+	// Algorithm is the TSIG algorithm, e.g. hmac-sha256. If unset, hmac-sha256 is used.
+	Algorithm string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`
+
+	// Timeout bounds each transfer. If unset, service.DefaultTransferTimeout is used.
+	Timeout time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+}
+
+// Source is one of several sources of groups. Type selects which of the other fields apply.
+type Source struct {
+	// Name identifies this source in logs and the admin API. If unset, the type and the
+	// position of this source are used, e.g. "transfer-1".
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// Type is the kind of source: zoneFiles, documents, transfer, or resolver.
+	Type string `json:"type" yaml:"type" mapstructure:"type"`
+
+	// ZoneFiles are the zone file globs of a zoneFiles source.
+	ZoneFiles []string `json:"zoneFiles" yaml:"zoneFiles" mapstructure:"zoneFiles"`
+
+	// Documents are the group document globs of a documents source.
+	Documents []string `json:"documents" yaml:"documents" mapstructure:"documents"`
+
+	// Transfers are the zones of a transfer source.
+	Transfers []Transfer `json:"transfers" yaml:"transfers" mapstructure:"transfers"`
+
+	// Resolvers are the resolver addresses of a resolver source.
+	Resolvers []string `json:"resolvers" yaml:"resolvers" mapstructure:"resolvers"`
+}
+
+// StaticEndpoint is an endpoint of a static group, which is the target of a single SRV record.
+type StaticEndpoint struct {
+	// Name is the endpoint's DNS name, e.g. talaria-1.example.net.
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// Addresses are the endpoint's IPv4 and IPv6 addresses.
+	Addresses []string `json:"addresses" yaml:"addresses" mapstructure:"addresses"`
+
+	// Port is the endpoint's port.
+	Port uint16 `json:"port" yaml:"port" mapstructure:"port"`
+
+	// Weight is the endpoint's SRV weight.
+	Weight uint16 `json:"weight" yaml:"weight" mapstructure:"weight"`
+
+	// Priority is the endpoint's SRV priority. Lower values are preferred.
+	Priority uint16 `json:"priority" yaml:"priority" mapstructure:"priority"`
+
+	// Attributes are the endpoint's attributes, e.g. drain: "true".
+	Attributes map[string]string `json:"attributes" yaml:"attributes" mapstructure:"attributes"`
+}
+
+// StaticService is a service, i.e. a set of SRV records, of a static group.
+type StaticService struct {
+	// Name is the service's DNS name, e.g. _talaria._tcp.example.net.
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// Endpoints are the endpoints of this service.
+	Endpoints []StaticEndpoint `json:"endpoints" yaml:"endpoints" mapstructure:"endpoints"`
+}
+
+// StaticGroup is a group declared directly in configuration rather than ingested.
+type StaticGroup struct {
+	// Name is the group's name.
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+
+	// Attributes are the group's attributes, e.g. algorithm: rendezvous.
+	Attributes map[string]string `json:"attributes" yaml:"attributes" mapstructure:"attributes"`
+
+	// Services are the services that hold the group's endpoints.
+	Services []StaticService `json:"services" yaml:"services" mapstructure:"services"`
+}
+
+// Validation configures the checks that the groups from each ingest must pass before they are
+// used. An ingest that fails any check is rejected, and the current groups stay in place.
+type Validation struct {
+	// RequireEndpoints rejects an ingest with any group that has no endpoints.
+	RequireEndpoints bool `json:"requireEndpoints" yaml:"requireEndpoints" mapstructure:"requireEndpoints"`
+
+	// RequireAddresses rejects an ingest with any SRV target that has no A or AAAA records.
+	RequireAddresses bool `json:"requireAddresses" yaml:"requireAddresses" mapstructure:"requireAddresses"`
+
+	// MaxRemovedPercent rejects an ingest that removes more than this percentage of the current
+	// endpoints, e.g. 25. If unset, any number of endpoints may be removed.
+	MaxRemovedPercent float64 `json:"maxRemovedPercent" yaml:"maxRemovedPercent" mapstructure:"maxRemovedPercent"`
 }
 
 // Groups holds the configuration necessary to establish hashy's groups.
@@ -74,16 +235,81 @@ type Groups struct {
 	// DefaultDiscoveryDomain.
 	DiscoveryDomain string `json:"discoveryDomain" yaml:"discoveryDomain" mapstructure:"discoveryDomain"`
 
-	// VNodes is the number of virtual nodes to use in consistent hashing.
+	// Algorithm is the default placement algorithm for groups: one of ring, rendezvous, jump,
+	// or maglev. A group can override this with an "algorithm=..." field in its definition.
+	// If unset, ring is used.
+	Algorithm string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`
+
+	// VNodes is the number of virtual nodes each endpoint has in consistent hashing. This only applies
+	// to the ring algorithm. If unset, service.DefaultVNodes is used.
 	VNodes int `json:"vnodes" yaml:"vnodes" mapstructure:"vnodes"`
 
+	// LoadFactor enables consistent hashing with bounded loads. No endpoint receives more than
+	// this factor times its share of its group's capacity, e.g. 1.25. If unset, loads are not bounded.
+	LoadFactor float64 `json:"loadFactor" yaml:"loadFactor" mapstructure:"loadFactor"`
+
+	// Replicas is the number of distinct endpoints returned for each group. The first
+	// endpoint is the nearest on the group's ring, and the rest are its successors, which
+	// clients can use for failover. If unset, one endpoint is returned for each group.
+	Replicas int `json:"replicas" yaml:"replicas" mapstructure:"replicas"`
+
 	// CheckInterval is the interval on which external sources of DNS RRs are rechecked
 	// to see if hashy may update its state. If unset, a service.DefaultCheckInterval is used.
 	CheckInterval time.Duration `json:"checkInterval" yaml:"checkInterval" mapstructure:"checkInterval"`
 
-	// ZoneFiles is a list of filesystem globs that contain group information.
+	// ZoneFiles is a list of filesystem globs that contain group information. A ** matches any
+	// number of directories, and a glob that begins with ! excludes the files it matches, e.g. "!**/*.bak".
 	ZoneFiles []string `json:"zoneFiles" yaml:"zoneFiles" mapstructure:"zoneFiles"`
 
+	// Documents is a list of filesystem globs for YAML or JSON group documents, which declare groups,
+	// services, and endpoints without DNS records. Globs work just like ZoneFiles. Documents cannot be
+	// combined with ZoneFiles, Transfers, or Resolvers.
+	Documents []string `json:"documents" yaml:"documents" mapstructure:"documents"`
+
+	// FileErrorPolicy is what happens when a zone file or document cannot be ingested: "fail" fails the whole
+	// ingest and keeps the current groups, while "skip" skips bad files and builds groups from the
+	// rest. Each bad file is reported either way. If unset, "fail" is used.
+	FileErrorPolicy string `json:"fileErrorPolicy" yaml:"fileErrorPolicy" mapstructure:"fileErrorPolicy"`
+
+	// RequireFiles fails any ingest in which the ZoneFiles or Documents globs match no files at all, which
+	// keeps the current groups when the files are unavailable, e.g. on an unmounted volume. By default,
+	// globs that match nothing produce empty groups.
+	RequireFiles bool `json:"requireFiles" yaml:"requireFiles" mapstructure:"requireFiles"`
+
+	// Watch enables watching the directories behind ZoneFiles and Documents, so that changes are ingested as
+	// soon as they happen. Polling on CheckInterval continues as a safety net.
+	Watch bool `json:"watch" yaml:"watch" mapstructure:"watch"`
+
+	// WatchDebounce is how long to wait for a burst of filesystem changes to end before ingesting.
+	// If unset, service.DefaultWatchDebounce is used.
+	WatchDebounce time.Duration `json:"watchDebounce" yaml:"watchDebounce" mapstructure:"watchDebounce"`
+
+	// Transfers are zones pulled from primary DNS servers instead of being read from
+	// zone files. Transfers and ZoneFiles cannot both be configured.
+	Transfers []Transfer `json:"transfers" yaml:"transfers" mapstructure:"transfers"`
+
+	// Resolvers are the addresses, as host or host:port, of DNS resolvers used to discover groups
+	// from live DNS instead of zone files. The discovery domain's TXT records are resolved, followed
+	// by their SRV services and then each target's addresses. Resolvers cannot be combined with
+	// ZoneFiles or Transfers.
+	Resolvers []string `json:"resolvers" yaml:"resolvers" mapstructure:"resolvers"`
+
+	// Snapshot is the file where the most recently accepted groups are saved. At startup, hashy serves
+	// the groups in this file until the first ingest succeeds. If unset, no snapshot is kept.
+	Snapshot string `json:"snapshot" yaml:"snapshot" mapstructure:"snapshot"`
+
+	// Validation configures the checks each ingest must pass before its groups are used.
+	Validation Validation `json:"validation" yaml:"validation" mapstructure:"validation"`
+
+	// Sources are several sources of groups whose records are merged, in order of precedence:
+	// an RRset from an earlier source hides the same RRset from later sources. Group definitions
+	// are combined from every source. Sources cannot be combined with ZoneFiles, Documents, Transfers, or Resolvers.
+	Sources []Source `json:"sources" yaml:"sources" mapstructure:"sources"`
+
+	// Static are groups declared directly in configuration. Static groups are merged with the groups
+	// from any other source, with the other sources taking precedence, and may be the only source of groups.
+	Static []StaticGroup `json:"static" yaml:"static" mapstructure:"static"`
+
 	// Origin is the origin to use when parsing zone files.
 	Origin string `json:"origin" yaml:"origin" mapstructure:"origin"`
 
@@ -91,6 +317,85 @@ type Groups struct {
 	DefaultTTL time.Duration `json:"defaultTTL" yaml:"defaultTTL" mapstructure:"defaultTTL"`
 }
 
+// Health configures active health checking of endpoints. Endpoints that fail their
+// health checks are left off their groups' rings until they recover.
+type Health struct {
+	// Probe is the kind of health check: either tcp, which connects to each endpoint's
+	// SRV port, or http, which issues a GET against each endpoint's SRV port. If unset,
+	// health checking is disabled.
+	Probe string `json:"probe" yaml:"probe" mapstructure:"probe"`
+
+	// Scheme is the URL scheme for http probes. If unset, http is used.
+	Scheme string `json:"scheme" yaml:"scheme" mapstructure:"scheme"`
+
+	// Path is the URL path for http probes. If unset, "/" is used.
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+
+	// Interval is the time between rounds of probes. If unset, service.DefaultHealthInterval is used.
+	Interval time.Duration `json:"interval" yaml:"interval" mapstructure:"interval"`
+
+	// Timeout is the time allowed for each probe. If unset, service.DefaultHealthTimeout is used.
+	Timeout time.Duration `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+
+	// UnhealthyThreshold is the number of consecutive failed probes before an endpoint
+	// is considered unhealthy. If unset, service.DefaultUnhealthyThreshold is used.
+	UnhealthyThreshold int `json:"unhealthyThreshold" yaml:"unhealthyThreshold" mapstructure:"unhealthyThreshold"`
+
+	// HealthyThreshold is the number of consecutive successful probes before an unhealthy
+	// endpoint is readmitted. If unset, service.DefaultHealthyThreshold is used.
+	HealthyThreshold int `json:"healthyThreshold" yaml:"healthyThreshold" mapstructure:"healthyThreshold"`
+}
+
+// HTTPServer is the configuration for a single HTTP server.
+type HTTPServer struct {
+	// Address is the address the server listens on, e.g. ":7374". If unset,
+	// the server is disabled.
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+
+	ReadTimeout  time.Duration `json:"readTimeout" yaml:"readTimeout" mapstructure:"readTimeout"`
+	WriteTimeout time.Duration `json:"writeTimeout" yaml:"writeTimeout" mapstructure:"writeTimeout"`
+	IdleTimeout  time.Duration `json:"idleTimeout" yaml:"idleTimeout" mapstructure:"idleTimeout"`
+}
+
+// Admin configures the HTTP admin server, which exposes hashy's live state as JSON. The admin
+// API is not authenticated, so its address should be on loopback, e.g. "127.0.0.1:7374".
+type Admin struct {
+	HTTPServer `yaml:",inline" mapstructure:",squash"`
+}
+
+// Metrics configures the HTTP server that exposes prometheus metrics.
+type Metrics struct {
+	HTTPServer `yaml:",inline" mapstructure:",squash"`
+
+	// Path is the URL path for metrics. If unset, "/metrics" is used.
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+}
+
+// Probes configures the HTTP server for liveness and readiness probes, e.g. for kubernetes.
+type Probes struct {
+	HTTPServer `yaml:",inline" mapstructure:",squash"`
+}
+
+// Tracing configures OpenTelemetry tracing, which is exported over OTLP/HTTP.
+type Tracing struct {
+	// Endpoint is the host and port of the OTLP/HTTP collector, e.g. "localhost:4318".
+	// If unset, tracing is disabled.
+	Endpoint string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
+
+	// URLPath is the URL path spans are sent to. If unset, the OTLP default of "/v1/traces" is used.
+	URLPath string `json:"urlPath" yaml:"urlPath" mapstructure:"urlPath"`
+
+	// Insecure disables TLS when connecting to the collector.
+	Insecure bool `json:"insecure" yaml:"insecure" mapstructure:"insecure"`
+
+	// Headers are additional HTTP headers sent with each export, e.g. for authorization.
+	Headers map[string]string `json:"headers" yaml:"headers" mapstructure:"headers"`
+
+	// SampleRatio is the fraction of new traces that are sampled, between 0.0 and 1.0.
+	// If unset, every trace is sampled.
+	SampleRatio float64 `json:"sampleRatio" yaml:"sampleRatio" mapstructure:"sampleRatio"`
+}
+
 // Main is the top-level configuration object for hashy.
 type Main struct {
 	// DNS holds all the information about the zone and the servers.
@@ -99,6 +404,21 @@ type Main struct {
 	// Groups defines how hashy obtains its groups.
 	Groups Groups `json:"groups" yaml:"groups" mapstructure:"groups"`
 
+	// Health configures optional active health checking of endpoints.
+	Health Health `json:"health" yaml:"health" mapstructure:"health"`
+
+	// Admin configures the optional HTTP admin server.
+	Admin Admin `json:"admin" yaml:"admin" mapstructure:"admin"`
+
+	// Metrics configures the optional prometheus metrics listener.
+	Metrics Metrics `json:"metrics" yaml:"metrics" mapstructure:"metrics"`
+
+	// Probes configures the optional liveness and readiness probe listener.
+	Probes Probes `json:"probes" yaml:"probes" mapstructure:"probes"`
+
+	// Tracing configures optional OpenTelemetry tracing.
+	Tracing Tracing `json:"tracing" yaml:"tracing" mapstructure:"tracing"`
+
 	// Logging is the server logging configuration.
 	Logging sallust.Config `json:"logging" yaml:"logging" mapstructure:"logging"`
 }
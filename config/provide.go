@@ -24,11 +24,36 @@ func Provide() fx.Option {
 			func(m Main) Groups {
 				return m.Groups
 			},
+			func(m Main) Health {
+				return m.Health
+			},
+			func(m Main) Admin {
+				return m.Admin
+			},
+			func(m Main) Metrics {
+				return m.Metrics
+			},
+			func(m Main) Probes {
+				return m.Probes
+			},
+			func(m Main) Tracing {
+				return m.Tracing
+			},
 			func(m Main) sallust.Config {
 				return m.Logging
 			},
-			func(d DNS) Zone {
-				return d.Zone
+			func(d DNS) Zones {
+				// the single zone is only used when there is no list of zones
+				if len(d.Zones) == 0 {
+					return Zones{d.Zone}
+				}
+
+				return d.Zones
+			},
+			// Deprecated: components should depend on Zones. The first zone is still provided
+			// for components written before hashy served several zones.
+			func(z Zones) Zone {
+				return z[0]
 			},
 			func(d DNS) UDPServers {
 				return d.UDP
@@ -36,6 +61,12 @@ func Provide() fx.Option {
 			func(d DNS) TCPServers {
 				return d.TCP
 			},
+			func(d DNS) Update {
+				return d.Update
+			},
+			func(d DNS) Notify {
+				return d.Notify
+			},
 		),
 	)
 }
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package hashyhttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/xmidt-org/hashy/config"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Server is an HTTP server for one of hashy's auxiliary APIs, such as the admin API.
+type Server struct {
+	logger *zap.Logger
+	server *http.Server
+}
+
+// NewServer creates an unstarted Server from configuration. The name is used for logging.
+func NewServer(name string, cfg config.HTTPServer, h http.Handler, base *zap.Logger) *Server {
+	return &Server{
+		logger: base.Named(name).With(zap.String("address", cfg.Address)),
+		server: &http.Server{
+			Addr:         cfg.Address,
+			Handler:      h,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		},
+	}
+}
+
+// Start listens on the configured address and serves requests in a separate goroutine.
+// If the server stops unexpectedly, the supplied shutdowner is used to shutdown the
+// enclosing fx.App.
+func (s *Server) Start(sh fx.Shutdowner) error {
+	l, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("starting server")
+	go func() {
+		// unlike dns.Server, net/http.Server returns ErrServerClosed when it stops normally
+		if err := s.server.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("server failed", zap.Error(err))
+			sh.Shutdown()
+		}
+	}()
+
+	return nil
+}
+
+// Stop gracefully stops the server.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// BindToLifecycle starts and stops this server with the enclosing fx.App.
+func (s *Server) BindToLifecycle(lc fx.Lifecycle, sh fx.Shutdowner) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return s.Start(sh)
+		},
+		OnStop: s.Stop,
+	})
+}
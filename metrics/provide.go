@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/hashyhttp"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultPath is the default URL path for metrics.
+	DefaultPath = "/metrics"
+)
+
+// Server is the HTTP server that exposes prometheus metrics.
+type Server struct {
+	*hashyhttp.Server
+}
+
+// NewRegistry creates the prometheus registry for hashy, which includes
+// the standard go runtime and process collectors.
+func NewRegistry() (r *prometheus.Registry, err error) {
+	r = prometheus.NewRegistry()
+	if err = r.Register(collectors.NewGoCollector()); err == nil {
+		err = r.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
+	return
+}
+
+// Provide creates the prometheus registry, as both a prometheus.Registerer and a
+// prometheus.Gatherer. The metrics server is only started when an address is configured.
+func Provide() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			NewRegistry,
+			func(r *prometheus.Registry) prometheus.Registerer {
+				return r
+			},
+			func(r *prometheus.Registry) prometheus.Gatherer {
+				return r
+			},
+			func(mcfg config.Metrics, g prometheus.Gatherer, base *zap.Logger, lc fx.Lifecycle, sh fx.Shutdowner) (s Server) {
+				if len(mcfg.Address) > 0 {
+					path := mcfg.Path
+					if len(path) == 0 {
+						path = DefaultPath
+					}
+
+					mux := http.NewServeMux()
+					mux.Handle("GET "+path, promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
+
+					s.Server = hashyhttp.NewServer("metrics", mcfg.HTTPServer, mux, base)
+					s.BindToLifecycle(lc, sh)
+				}
+
+				return
+			},
+		),
+		fx.Invoke(
+			// ensure that the metrics server is always created
+			func(Server) {},
+		),
+	)
+}
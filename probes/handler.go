@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package probes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/xmidt-org/hashy/service"
+)
+
+const (
+	// LivenessPath is the URL path of the liveness probe.
+	LivenessPath = "/livez"
+
+	// ReadinessPath is the URL path of the readiness probe.
+	ReadinessPath = "/readyz"
+)
+
+// Status is the JSON body of a probe response.
+type Status struct {
+	Status string `json:"status"`
+}
+
+// NewHandler creates the handler for the liveness and readiness probes. The liveness probe
+// always succeeds while hashy is serving. The readiness probe responds with 503 Service
+// Unavailable until the given Readiness is ready.
+func NewHandler(r service.Readiness) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+LivenessPath, func(response http.ResponseWriter, _ *http.Request) {
+		writeStatus(response, http.StatusOK, "live")
+	})
+
+	mux.HandleFunc("GET "+ReadinessPath, func(response http.ResponseWriter, _ *http.Request) {
+		if r.Ready() {
+			writeStatus(response, http.StatusOK, "ready")
+		} else {
+			writeStatus(response, http.StatusServiceUnavailable, "not ready")
+		}
+	})
+
+	return mux
+}
+
+func writeStatus(response http.ResponseWriter, code int, status string) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(code)
+	json.NewEncoder(response).Encode(Status{Status: status})
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package probes
+
+import (
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/hashyhttp"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Server is the HTTP server for the liveness and readiness probes.
+type Server struct {
+	*hashyhttp.Server
+}
+
+// Provide builds the probes. The probe server is only started when an address is configured.
+func Provide() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			func(pcfg config.Probes, loc *service.Locator, base *zap.Logger, lc fx.Lifecycle, sh fx.Shutdowner) (s Server) {
+				if len(pcfg.Address) > 0 {
+					s.Server = hashyhttp.NewServer("probes", pcfg.HTTPServer, NewHandler(loc), base)
+					s.BindToLifecycle(lc, sh)
+				}
+
+				return
+			},
+		),
+		fx.Invoke(
+			// ensure that the probe server is always created
+			func(Server) {},
+		),
+	)
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ACL is an access control list of the network prefixes that clients are allowed
+// to connect from. An empty ACL allows no clients.
+type ACL []netip.Prefix
+
+// ParseACL parses a list of CIDR prefixes, e.g. "10.0.0.0/8". A bare address is
+// taken to be a prefix containing only that address.
+func ParseACL(v []string) (acl ACL, err error) {
+	acl = make(ACL, 0, len(v))
+	for _, s := range v {
+		var p netip.Prefix
+		if strings.ContainsRune(s, '/') {
+			p, err = netip.ParsePrefix(s)
+		} else {
+			var addr netip.Addr
+			if addr, err = netip.ParseAddr(s); err == nil {
+				p = netip.PrefixFrom(addr, addr.BitLen())
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACL entry %q: %w", s, err)
+		}
+
+		acl = append(acl, p.Masked())
+	}
+
+	return
+}
+
+// Allows tests if a client address is within any prefix of this ACL.
+func (acl ACL) Allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range acl {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientAddr extracts the IP address of a client from a network address. If the
+// address is not an IP address, the zero netip.Addr is returned.
+func clientAddr(a net.Addr) (addr netip.Addr) {
+	switch ta := a.(type) {
+	case *net.UDPAddr:
+		addr = ta.AddrPort().Addr()
+
+	case *net.TCPAddr:
+		addr = ta.AddrPort().Addr()
+
+	default:
+		if a != nil {
+			if ap, err := netip.ParseAddrPort(a.String()); err == nil {
+				addr = ap.Addr()
+			}
+		}
+	}
+
+	return addr.Unmap()
+}
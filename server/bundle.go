@@ -86,7 +86,7 @@ func (m Bundle) UseLogger(parent *zap.Logger) {
 // handles server-specific logging in handlers.
 func (m Bundle) UseHandler(base *Handler) {
 	for name, info := range m {
-		info.Server.Handler = base.Clone(info.Logger)
+		info.Server.Handler = base.Clone(name, info.Logger)
 		m[name] = info
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"codeberg.org/miekg/dns/dnsutil"
 	"github.com/xmidt-org/hashy"
 	"github.com/xmidt-org/hashy/service"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -107,8 +108,27 @@ func NewEndpointHandler(opts ...EndpointHandlerOption) (*EndpointHandler, error)
 	return eh, nil
 }
 
-func (eh *EndpointHandler) ServeRequest(_ context.Context, _ *zap.Logger, response *dns.Msg, request EndpointRequest) {
-	endpoints := eh.locator.FindString(request.object, request.groups...)
+// ServeRequest answers with the addresses of the endpoints an object hashes to. When
+// more than one endpoint per group is located, the nearest endpoints are always answered
+// first, followed by each rank of successors. Records are shuffled only within a rank, so
+// clients that try addresses in order will reach a group's nearest endpoint first.
+//
+// Draining endpoints are never answered, as answers are for new connections.
+func (eh *EndpointHandler) ServeRequest(ctx context.Context, _ *zap.Logger, response *dns.Msg, request EndpointRequest) {
+	endpoints := eh.locator.AssignString(request.object, request.groups...)
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		names := make([]string, 0, len(endpoints))
+		for _, le := range endpoints {
+			names = append(names, le.OriginalName())
+		}
+
+		span.SetAttributes(
+			prefixKey.String(request.prefix),
+			groupsKey.StringSlice(request.groups),
+			endpointsKey.StringSlice(names),
+		)
+	}
+
 	response.Answer = slices.Grow(response.Answer, endpoints.LenRRs(request.rrType))
 
 	header := dns.Header{
@@ -117,10 +137,16 @@ func (eh *EndpointHandler) ServeRequest(_ context.Context, _ *zap.Logger, respon
 		Class: dns.ClassINET,
 	}
 
-	for _, rr := range endpoints.RRs(request.rrType) {
+	start, rank := len(response.Answer), 0
+	for le, rr := range endpoints.RRs(request.rrType) {
+		if le.Rank != rank {
+			hashy.Shuffle(response.Answer[start:])
+			start, rank = len(response.Answer), le.Rank
+		}
+
 		*rr.Header() = header
 		response.Answer = append(response.Answer, rr)
 	}
 
-	hashy.Shuffle(response.Answer)
+	hashy.Shuffle(response.Answer[start:])
 }
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strconv"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/rdata"
+	"github.com/xmidt-org/hashy/service"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ExplainRequest holds the information from a DNS question for explaining how an object
+// was placed. The question has the same form as an endpoint request.
+type ExplainRequest struct {
+	EndpointRequest
+
+	client netip.Addr
+}
+
+// ParseExplainRequest parses the question into a request to explain an object's placement.
+// The client is the remote address of the request, which is checked against the ACL.
+func ParseExplainRequest(question dns.RR, domain string, client net.Addr) ExplainRequest {
+	return ExplainRequest{
+		EndpointRequest: ParseEndpointRequest(question, domain),
+		client:          clientAddr(client),
+	}
+}
+
+type ExplainHandlerOption interface {
+	applyToExplainHandler(*ExplainHandler) error
+}
+
+type explainHandlerOptionFunc func(*ExplainHandler) error
+
+func (f explainHandlerOptionFunc) applyToExplainHandler(xh *ExplainHandler) error { return f(xh) }
+
+func WithExplainLocator(locator *service.Locator) ExplainHandlerOption {
+	return explainHandlerOptionFunc(func(xh *ExplainHandler) error {
+		xh.locator = locator
+		return nil
+	})
+}
+
+// WithExplainACL sets the clients allowed to make explain requests. By default, no
+// clients are allowed.
+func WithExplainACL(acl ACL) ExplainHandlerOption {
+	return explainHandlerOptionFunc(func(xh *ExplainHandler) error {
+		xh.acl = append(xh.acl, acl...)
+		return nil
+	})
+}
+
+// ExplainHandler answers diagnostic TXT records describing how an object was placed
+// within each group. Since this exposes the internals of placement, only clients
+// within the ACL are answered.
+type ExplainHandler struct {
+	locator *service.Locator
+	acl     ACL
+}
+
+func NewExplainHandler(opts ...ExplainHandlerOption) (*ExplainHandler, error) {
+	xh := new(ExplainHandler)
+	for _, o := range opts {
+		if err := o.applyToExplainHandler(xh); err != nil {
+			return nil, err
+		}
+	}
+
+	if xh.locator == nil {
+		return nil, errors.New("a locator is required")
+	}
+
+	return xh, nil
+}
+
+// explainTXT produces the strings of the TXT record for an explanation.
+func explainTXT(e service.Explanation) []string {
+	txt := []string{
+		"group=" + e.Group,
+		"algorithm=" + string(e.Algorithm),
+		"object=" + e.Object,
+		"hash=" + strconv.FormatUint(e.Hash, 16),
+		"token=" + e.Token,
+	}
+
+	if e.Endpoint != nil {
+		txt = append(txt, "endpoint="+e.Endpoint.OriginalName())
+	}
+
+	if e.Successor != nil {
+		txt = append(txt, "successor="+e.Successor.OriginalName())
+	}
+
+	return txt
+}
+
+// ServeRequest answers with one TXT record for each group, describing how the object
+// was placed in that group. Explanations have a TTL of zero, so they are never cached.
+func (xh *ExplainHandler) ServeRequest(ctx context.Context, logger *zap.Logger, response *dns.Msg, request ExplainRequest) {
+	if !xh.acl.Allows(request.client) {
+		logger.Warn("explain request denied", zap.Stringer("client", request.client))
+		response.Rcode = dns.RcodeRefused
+		return
+	}
+
+	// explanations are only communicated via TXT records
+	if request.rrType != dns.TypeTXT {
+		response.Rcode = dns.RcodeRefused
+		return
+	}
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(
+			prefixKey.String(request.prefix),
+			groupsKey.StringSlice(request.groups),
+		)
+	}
+
+	header := dns.Header{
+		Name:  request.name,
+		Class: dns.ClassINET,
+	}
+
+	for _, e := range xh.locator.ExplainString(request.object, request.groups...) {
+		response.Answer = append(response.Answer, &dns.TXT{
+			Hdr: header,
+			TXT: rdata.TXT{
+				Txt: explainTXT(e),
+			},
+		})
+	}
+}
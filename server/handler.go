@@ -6,12 +6,18 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"codeberg.org/miekg/dns"
 	"codeberg.org/miekg/dns/dnsutil"
 	"github.com/xmidt-org/hashy/hashyzap"
+	"github.com/xmidt-org/hashy/service"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"go.uber.org/zap"
 )
 
@@ -19,11 +25,24 @@ const (
 	// DefaultZoneDomain is the default DNS domain that hashy serves.
 	DefaultZoneDomain = "hashy.net"
 
+	// DefaultEndpointLabel is the default subdomain of a zone domain that handles endpoint hashes.
+	DefaultEndpointLabel = "endpoint"
+
+	// DefaultGroupLabel is the default subdomain of a zone domain that handles group DNS lookups.
+	DefaultGroupLabel = "group"
+
 	// EndpointLabel defines the subdomain of the zone domain that handles endpoint hashes.
-	EndpointLabel = "endpoint"
+	//
+	// Deprecated: use DefaultEndpointLabel, or Zone.EndpointLabel to change it per zone.
+	EndpointLabel = DefaultEndpointLabel
 
 	// GroupLabel defines the subdomain of the zone domain that handles group DNS lookups.
-	GroupLabel = "group"
+	//
+	// Deprecated: use DefaultGroupLabel, or Zone.GroupLabel to change it per zone.
+	GroupLabel = DefaultGroupLabel
+
+	// DefaultExplainLabel is the default subdomain of a zone domain that explains placements.
+	DefaultExplainLabel = "explain"
 
 	// DefaultZoneTTL is the default time-to-live for records generated within
 	// hashy's zone.
@@ -45,23 +64,96 @@ func WithLogger(base *zap.Logger) HandlerOption {
 	})
 }
 
+// WithMetrics sets the prometheus metrics a Handler records. By default, no metrics are recorded.
+func WithMetrics(m *Metrics) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.metrics = m
+		return nil
+	})
+}
+
+// WithTracerProvider sets the OpenTelemetry provider a Handler uses to create a span for
+// each DNS request. By default, no spans are recorded.
+func WithTracerProvider(tp trace.TracerProvider) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		if tp != nil {
+			h.tracer = tp.Tracer(TracerName)
+		}
+
+		return nil
+	})
+}
+
+// WithReadiness gates answers on readiness. Until the given Readiness is ready, requests
+// within hashy's zones are answered with SERVFAIL and, for EDNS requests, an extended
+// DNS error of "Not Ready". By default, a Handler is always ready.
+func WithReadiness(r service.Readiness) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.readiness = r
+		return nil
+	})
+}
+
+// WithUpdate sets the handler for RFC 2136 UPDATE messages. By default, updates are refused.
+func WithUpdate(uh *UpdateHandler) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.update = uh
+		return nil
+	})
+}
+
+// WithNotify sets the handler for RFC 1996 NOTIFY messages. By default, NOTIFY messages are refused.
+func WithNotify(nh *NotifyHandler) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.notify = nh
+		return nil
+	})
+}
+
+// WithZones adds zones that a Handler serves. Each zone must have a distinct domain.
+func WithZones(more ...Zone) HandlerOption {
+	return handlerOptionFunc(func(h *Handler) error {
+		h.zones = slices.Grow(h.zones, len(more))
+		for _, z := range more {
+			nz, err := newZone(z)
+			if err != nil {
+				return err
+			}
+
+			h.zones = append(h.zones, nz)
+		}
+
+		return nil
+	})
+}
+
+// WithZoneDomain sets the domain of a single zone configured with WithEndpointHandler
+// and WithGroupHandler.
+//
+// Deprecated: use WithZones.
 func WithZoneDomain(d string) HandlerOption {
 	return handlerOptionFunc(func(h *Handler) error {
-		h.zoneDomain = dnsutil.Fqdn(d)
+		h.legacy.Domain = d
 		return nil
 	})
 }
 
+// WithEndpointHandler sets the endpoint handler of a single zone.
+//
+// Deprecated: use WithZones.
 func WithEndpointHandler(eh *EndpointHandler) HandlerOption {
 	return handlerOptionFunc(func(h *Handler) error {
-		h.endpointHandler = eh
+		h.legacy.EndpointHandler = eh
 		return nil
 	})
 }
 
+// WithGroupHandler sets the group handler of a single zone.
+//
+// Deprecated: use WithZones.
 func WithGroupHandler(gh *GroupHandler) HandlerOption {
 	return handlerOptionFunc(func(h *Handler) error {
-		h.groupHandler = gh
+		h.legacy.GroupHandler = gh
 		return nil
 	})
 }
@@ -76,20 +168,36 @@ type operation struct {
 
 	logger   *zap.Logger
 	response *dns.Msg
+	span     trace.Span
+
+	// tsig signs the response, if the request was signed
+	tsig *responseTSIG
+
+	// these fields describe the request for metrics
+	metrics   *Metrics
+	server    string
+	zone      string
+	subdomain string
+	qtype     uint16
 }
 
 // startOperation initializes a new operation from a DNS request.
-func startOperation(ctx context.Context, base *zap.Logger, writer dns.ResponseWriter, original *dns.Msg) (op operation) {
-	op.ctx = ctx
+func (h *Handler) startOperation(ctx context.Context, writer dns.ResponseWriter, original *dns.Msg) (op operation) {
+	op.ctx, op.span = h.tracer.Start(ctx, "dns.request", trace.WithSpanKind(trace.SpanKindServer))
 	op.writer = writer
 	op.original = original
 	op.start = time.Now()
 
+	op.metrics = h.metrics
+	op.server = h.server
+	op.zone = NoLabel
+	op.subdomain = NoLabel
+
 	op.response = op.original.Copy()
 	op.response.Rcode = dns.RcodeSuccess // default
 	dnsutil.SetReply(op.response, op.original)
 
-	op.logger = base.With(
+	op.logger = h.logger.With(
 		hashyzap.Request("request", op.original),
 	)
 
@@ -111,11 +219,30 @@ func (op *operation) getQuestion() (question dns.RR) {
 
 	default:
 		question = op.original.Question[0]
+		op.qtype = dns.RRToType(question)
+		op.span.SetAttributes(
+			questionNameKey.String(question.Header().Name),
+			questionTypeKey.String(typeLabel(op.qtype)),
+		)
 	}
 
 	return
 }
 
+// notReady reports that hashy cannot answer this operation yet.
+func (op *operation) notReady() {
+	op.logger.Warn("not ready")
+	op.response.Rcode = dns.RcodeServerFailure
+
+	// an extended error requires EDNS, which the request must have used
+	if op.original.UDPSize > 0 {
+		op.response.Pseudo = append(op.response.Pseudo, &dns.EDE{
+			InfoCode:  dns.ExtendedErrorNotReady,
+			ExtraText: "no groups are available",
+		})
+	}
+}
+
 // unhandled reports this operation as unhandled and indicates this in the response.
 func (op *operation) unhandled() {
 	op.logger.Error("unhandled request")
@@ -124,9 +251,17 @@ func (op *operation) unhandled() {
 
 // finish performs all the necessary completion tasks for an operation.
 func (op *operation) finish() {
+	if op.tsig != nil {
+		op.response.Pseudo = append(op.response.Pseudo, op.tsig.stub())
+	}
+
 	var err error
 	if err = op.response.Pack(); err != nil {
 		op.logger.Error("unable to pack response", zap.Error(err))
+	} else if op.tsig != nil {
+		if err = op.tsig.sign(op.response); err != nil {
+			op.logger.Error("unable to sign response", zap.Error(err))
+		}
 	}
 
 	if err == nil {
@@ -135,31 +270,56 @@ func (op *operation) finish() {
 		}
 	}
 
-	op.logger.Info("request complete", zap.Duration("duration", time.Since(op.start)))
+	duration := time.Since(op.start)
+	op.metrics.observe(op.server, op.zone, op.subdomain, op.qtype, op.response.Rcode, duration)
+	op.logger.Info("request complete", zap.Duration("duration", duration))
+
+	op.span.SetAttributes(
+		zoneKey.String(op.zone),
+		subdomainKey.String(op.subdomain),
+		rcodeKey.String(rcodeLabel(op.response.Rcode)),
+	)
+
+	if err != nil {
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+
+	op.span.End()
 }
 
 // Handler is the main DNS handler for hashy. Most of hashy's logic is contained
 // in this type.
 //
-// A Handler routes to some internal handlers based on domain.
+// A Handler serves one or more zones, and routes to some internal handlers based on domain.
 type Handler struct {
 	// logger is the logger for this Handler, typically enhanced with server information.
 	logger *zap.Logger
 
-	// zoneDomain is the domain this Handler serves.
-	zoneDomain string
+	// server is the name of the server this Handler is cloned for, if any.
+	server string
+
+	// metrics is the optional prometheus metrics for this Handler.
+	metrics *Metrics
+
+	// tracer creates the span for each request.
+	tracer trace.Tracer
+
+	// readiness is the optional gate for answering requests.
+	readiness service.Readiness
 
-	// endpointDomain is the subdomain the endpoint handler serves.
-	endpointDomain string
+	// update is the optional handler for UPDATE messages.
+	update *UpdateHandler
 
-	// endpointHandler is the dns.Handler that serves hashed responses.
-	endpointHandler *EndpointHandler
+	// notify is the optional handler for NOTIFY messages.
+	notify *NotifyHandler
 
-	// groupDomain is the subdomain the group handler serves.
-	groupDomain string
+	// zones are the zones this Handler serves, ordered from the most specific
+	// domain to the least specific.
+	zones []zone
 
-	// groupHandler is the dns.Handler servers metadata about groups.
-	groupHandler *GroupHandler
+	// legacy is the single zone configured by the deprecated options, if any.
+	legacy Zone
 }
 
 // NewHandler creates a Handler from a set of options.
@@ -171,35 +331,54 @@ func NewHandler(opts ...HandlerOption) (*Handler, error) {
 		}
 	}
 
-	if h.endpointHandler == nil {
-		return nil, errors.New("an endpoint handler is required")
+	if h.legacy != (Zone{}) {
+		nz, err := newZone(h.legacy)
+		if err != nil {
+			return nil, err
+		}
+
+		h.zones = append(h.zones, nz)
 	}
 
-	if h.groupHandler == nil {
-		return nil, errors.New("a group handler is required")
+	if len(h.zones) == 0 {
+		return nil, errors.New("at least one zone is required")
 	}
 
 	if h.logger == nil {
 		return nil, errors.New("a base logger is required")
 	}
 
-	if len(h.zoneDomain) == 0 {
-		h.zoneDomain = DefaultZoneDomain
+	if h.tracer == nil {
+		h.tracer = noop.NewTracerProvider().Tracer(TracerName)
 	}
 
-	h.endpointDomain = dnsutil.Join(EndpointLabel, h.zoneDomain)
-	h.groupDomain = dnsutil.Join(GroupLabel, h.zoneDomain)
+	// sort the zones so that the most specific domain is matched first, which
+	// allows zones to be nested within each other
+	slices.SortStableFunc(h.zones, func(z1, z2 zone) int {
+		return dnsutil.Labels(z2.domain) - dnsutil.Labels(z1.domain)
+	})
+
+	domains := make(map[string]bool, len(h.zones))
+	for _, z := range h.zones {
+		domain := dnsutil.Canonical(z.domain)
+		if domains[domain] {
+			return nil, fmt.Errorf("duplicate zone domain: %s", z.domain)
+		}
+
+		domains[domain] = true
+	}
 
 	return h, nil
 }
 
-// Clone creates a copy of this handler that uses the given logger, which is
-// typically a server-specific logger.
+// Clone creates a copy of this handler for a named server that uses the given logger,
+// which is typically a server-specific logger. The server name is used in metrics.
 //
 // If logger is nil, zap.NewNop() is used.
-func (h *Handler) Clone(logger *zap.Logger) *Handler {
+func (h *Handler) Clone(server string, logger *zap.Logger) *Handler {
 	clone := new(Handler)
 	*clone = *h
+	clone.server = server
 	clone.logger = logger
 	if clone.logger == nil {
 		clone.logger = zap.NewNop()
@@ -208,8 +387,20 @@ func (h *Handler) Clone(logger *zap.Logger) *Handler {
 	return clone
 }
 
+// findZone returns the most specific zone that contains the given name.
+// If no zone contains the name, this method returns nil.
+func (h *Handler) findZone(name string) *zone {
+	for i := range h.zones {
+		if dnsutil.IsBelow(h.zones[i].domain, name) {
+			return &h.zones[i]
+		}
+	}
+
+	return nil
+}
+
 func (h *Handler) ServeDNS(ctx context.Context, writer dns.ResponseWriter, request *dns.Msg) {
-	op := startOperation(ctx, h.logger, writer, request)
+	op := h.startOperation(ctx, writer, request)
 	defer op.finish()
 
 	question := op.getQuestion()
@@ -217,21 +408,77 @@ func (h *Handler) ServeDNS(ctx context.Context, writer dns.ResponseWriter, reque
 		return
 	}
 
+	if op.original.Opcode == dns.OpcodeUpdate {
+		// an update's zone is a source of groups, rather than one of hashy's zones
+		op.subdomain = updateSubdomain
+		if h.update != nil {
+			op.tsig = h.update.ServeRequest(
+				op.ctx,
+				op.logger,
+				op.response,
+				ParseUpdateRequest(op.original, question, op.writer.RemoteAddr()),
+			)
+		} else {
+			op.unhandled()
+		}
+
+		return
+	}
+
+	if op.original.Opcode == dns.OpcodeNotify {
+		// like an update, a NOTIFY names a source of groups
+		op.subdomain = notifySubdomain
+		if h.notify != nil {
+			h.notify.ServeRequest(
+				op.ctx,
+				op.logger,
+				op.response,
+				ParseNotifyRequest(question, op.writer.RemoteAddr()),
+			)
+		} else {
+			op.unhandled()
+		}
+
+		return
+	}
+
+	z := h.findZone(question.Header().Name)
+	if z != nil {
+		op.zone = z.domain
+	}
+
 	switch {
-	case dnsutil.IsBelow(h.endpointDomain, question.Header().Name):
-		h.endpointHandler.ServeRequest(
+	case z == nil:
+		op.unhandled()
+
+	case h.readiness != nil && !h.readiness.Ready():
+		op.notReady()
+
+	case dnsutil.IsBelow(z.endpointDomain, question.Header().Name):
+		op.subdomain = endpointSubdomain
+		z.endpointHandler.ServeRequest(
+			op.ctx,
+			op.logger,
+			op.response,
+			ParseEndpointRequest(question, z.endpointDomain),
+		)
+
+	case dnsutil.IsBelow(z.groupDomain, question.Header().Name):
+		op.subdomain = groupSubdomain
+		z.groupHandler.ServeRequest(
 			op.ctx,
 			op.logger,
 			op.response,
-			ParseEndpointRequest(question, h.endpointDomain),
+			ParseGroupRequest(question, z.groupDomain),
 		)
 
-	case dnsutil.IsBelow(h.groupDomain, question.Header().Name):
-		h.groupHandler.ServeRequest(
+	case z.explainHandler != nil && dnsutil.IsBelow(z.explainDomain, question.Header().Name):
+		op.subdomain = explainSubdomain
+		z.explainHandler.ServeRequest(
 			op.ctx,
 			op.logger,
 			op.response,
-			ParseGroupRequest(question, h.groupDomain),
+			ParseExplainRequest(question, z.explainDomain, op.writer.RemoteAddr()),
 		)
 
 	default:
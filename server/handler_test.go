@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/service"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// bufferWriter is a dns.ResponseWriter that keeps the written response.
+type bufferWriter struct {
+	data []byte
+}
+
+func (bw *bufferWriter) Write(p []byte) (int, error) {
+	bw.data = append(bw.data, p...)
+	return len(p), nil
+}
+
+func (bw *bufferWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+}
+func (bw *bufferWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5353}
+}
+func (bw *bufferWriter) Conn() net.Conn        { return nil }
+func (bw *bufferWriter) Close() error          { return nil }
+func (bw *bufferWriter) Session() *dns.Session { return nil }
+func (bw *bufferWriter) Hijack()               {}
+
+// newSampleLocator creates a Locator holding the groups of the sample zone files.
+func newSampleLocator(t *testing.T) *service.Locator {
+	t.Helper()
+	loc, err := service.NewLocator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := service.NewFileIngester(
+		service.WithGlobs("../sample/*.zone"),
+		service.WithIngestListeners(loc),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi.Ingest(context.Background())
+	if !loc.Ready() {
+		t.Fatal("the sample groups were not ingested")
+	}
+
+	return loc
+}
+
+// attributes returns a span's attributes by key.
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		m[kv.Key] = kv.Value
+	}
+
+	return m
+}
+
+func TestHandlerSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	z, err := NewZone(config.Zone{Domain: DefaultZoneDomain}, newSampleLocator(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewHandler(
+		WithLogger(zap.NewNop()),
+		WithTracerProvider(tp),
+		WithZones(z),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		qtype     uint16
+		subdomain string
+		rcode     string
+		extra     map[attribute.Key]attribute.Value
+	}{
+		{
+			name:      "mac-112233445566.useast1.endpoint.hashy.net.",
+			qtype:     dns.TypeA,
+			subdomain: endpointSubdomain,
+			rcode:     "NOERROR",
+			extra: map[attribute.Key]attribute.Value{
+				prefixKey: attribute.StringValue("mac"),
+				groupsKey: attribute.StringSliceValue([]string{"useast1"}),
+			},
+		},
+		{
+			name:      "useast2.group.hashy.net.",
+			qtype:     dns.TypeTXT,
+			subdomain: groupSubdomain,
+			rcode:     "NOERROR",
+		},
+		{
+			name:      "nothing.example.com.",
+			qtype:     dns.TypeA,
+			subdomain: NoLabel,
+			rcode:     "REFUSED",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			exporter.Reset()
+			var writer bufferWriter
+			h.ServeDNS(context.Background(), &writer, dns.NewMsg(testCase.name, testCase.qtype))
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("expected exactly one span, got %d", len(spans))
+			}
+
+			span := spans[0]
+			if span.Name != "dns.request" || span.SpanKind != trace.SpanKindServer {
+				t.Errorf("unexpected span %s of kind %s", span.Name, span.SpanKind)
+			}
+
+			expected := map[attribute.Key]attribute.Value{
+				questionNameKey: attribute.StringValue(testCase.name),
+				questionTypeKey: attribute.StringValue(typeLabel(testCase.qtype)),
+				subdomainKey:    attribute.StringValue(testCase.subdomain),
+				rcodeKey:        attribute.StringValue(testCase.rcode),
+			}
+
+			for k, v := range testCase.extra {
+				expected[k] = v
+			}
+
+			actual := attributes(span)
+			for k, v := range expected {
+				if actual[k] != v {
+					t.Errorf("expected %s=%s, got %s", k, v.Emit(), actual[k].Emit())
+				}
+			}
+
+			if testCase.subdomain == endpointSubdomain && len(actual[endpointsKey].AsStringSlice()) == 0 {
+				t.Error("expected the located endpoints to be recorded")
+			}
+		})
+	}
+}
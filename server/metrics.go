@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// NoLabel is the metric label value used when a request didn't reach a zone or subdomain.
+	NoLabel = "none"
+
+	// endpointSubdomain, groupSubdomain, and explainSubdomain are the subdomain metric label values, which
+	// are the same regardless of how each zone's labels are configured. updateSubdomain and notifySubdomain
+	// label UPDATE and NOTIFY messages.
+	endpointSubdomain = "endpoint"
+	groupSubdomain    = "group"
+	explainSubdomain  = "explain"
+	updateSubdomain   = "update"
+	notifySubdomain   = "notify"
+)
+
+// Metrics holds the prometheus collectors for DNS request handling.
+type Metrics struct {
+	// Queries counts DNS requests by server, zone, subdomain, qtype, and rcode.
+	Queries *prometheus.CounterVec
+
+	// Duration is the latency of DNS requests by server, zone, subdomain, and qtype.
+	Duration *prometheus.HistogramVec
+}
+
+// NewMetrics creates the DNS request metrics and registers them with the given Registerer.
+func NewMetrics(r prometheus.Registerer) (m *Metrics, err error) {
+	m = &Metrics{
+		Queries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "hashy",
+				Subsystem: "dns",
+				Name:      "queries_total",
+				Help:      "The number of DNS requests handled.",
+			},
+			[]string{"server", "zone", "subdomain", "qtype", "rcode"},
+		),
+		Duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "hashy",
+				Subsystem: "dns",
+				Name:      "query_duration_seconds",
+				Help:      "The time taken to handle DNS requests.",
+				Buckets:   []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1},
+			},
+			[]string{"server", "zone", "subdomain", "qtype"},
+		),
+	}
+
+	for _, c := range []prometheus.Collector{m.Queries, m.Duration} {
+		if err = r.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// typeLabel returns the metric label for a DNS type.
+func typeLabel(t uint16) string {
+	if s, exists := dns.TypeToString[t]; exists {
+		return s
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+// rcodeLabel returns the metric label for a DNS rcode.
+func rcodeLabel(rcode uint16) string {
+	if s, exists := dns.RcodeToString[rcode]; exists {
+		return s
+	}
+
+	return strconv.Itoa(int(rcode))
+}
+
+// observe records the outcome of a single DNS request. A nil Metrics records nothing.
+func (m *Metrics) observe(server, zone, subdomain string, qtype, rcode uint16, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	qtypeLabel := typeLabel(qtype)
+	m.Queries.WithLabelValues(server, zone, subdomain, qtypeLabel, rcodeLabel(rcode)).Inc()
+	m.Duration.WithLabelValues(server, zone, subdomain, qtypeLabel).Observe(duration.Seconds())
+}
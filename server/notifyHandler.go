@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/zap"
+)
+
+// IngestTrigger requests an ingest outside of the usual interval. A *service.IngestChecker
+// implements this interface.
+type IngestTrigger = service.IngestTrigger
+
+// ZoneTrigger requests an ingest from the source that owns a zone. A *service.IngestChecker
+// implements this interface.
+type ZoneTrigger = service.ZoneTrigger
+
+// NotifyRequest holds an RFC 1996 NOTIFY message.
+type NotifyRequest struct {
+	zone   string
+	qtype  uint16
+	client netip.Addr
+}
+
+// ParseNotifyRequest extracts the information necessary to process a NOTIFY message.
+func ParseNotifyRequest(question dns.RR, client net.Addr) NotifyRequest {
+	return NotifyRequest{
+		zone:   dnsutil.Canonical(question.Header().Name),
+		qtype:  dns.RRToType(question),
+		client: clientAddr(client),
+	}
+}
+
+type NotifyHandlerOption interface {
+	applyToNotifyHandler(*NotifyHandler) error
+}
+
+type notifyHandlerOptionFunc func(*NotifyHandler) error
+
+func (f notifyHandlerOptionFunc) applyToNotifyHandler(nh *NotifyHandler) error { return f(nh) }
+
+// WithNotifyTrigger sets what a NOTIFY triggers, which also decides whether any source
+// owns the NOTIFY's zone. This option is required.
+func WithNotifyTrigger(t ZoneTrigger) NotifyHandlerOption {
+	return notifyHandlerOptionFunc(func(nh *NotifyHandler) error {
+		nh.trigger = t
+		return nil
+	})
+}
+
+// WithNotifyZones restricts the zones that NOTIFY messages may name. By default, a NOTIFY
+// may name any zone that a source owns.
+func WithNotifyZones(zones ...string) NotifyHandlerOption {
+	return notifyHandlerOptionFunc(func(nh *NotifyHandler) error {
+		if nh.zones == nil {
+			nh.zones = make(map[string]bool, len(zones))
+		}
+
+		for _, z := range zones {
+			nh.zones[dnsutil.Canonical(z)] = true
+		}
+
+		return nil
+	})
+}
+
+// WithNotifyACL adds the primaries allowed to send NOTIFY messages. At least one is required.
+func WithNotifyACL(acl ACL) NotifyHandlerOption {
+	return notifyHandlerOptionFunc(func(nh *NotifyHandler) error {
+		nh.acl = append(nh.acl, acl...)
+		return nil
+	})
+}
+
+// NotifyHandler processes RFC 1996 NOTIFY messages, which primaries send when a zone changes.
+// A NOTIFY from an allowed primary triggers an immediate ingest by the source that owns
+// the zone, such as the zone transfer that pulls it. A NOTIFY for a zone that no source owns
+// is refused. Since triggers coalesce, a burst of NOTIFY messages results in a single ingest.
+type NotifyHandler struct {
+	trigger ZoneTrigger
+	zones   map[string]bool
+	acl     ACL
+}
+
+func NewNotifyHandler(opts ...NotifyHandlerOption) (*NotifyHandler, error) {
+	nh := new(NotifyHandler)
+	for _, o := range opts {
+		if err := o.applyToNotifyHandler(nh); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case nh.trigger == nil:
+		return nil, errors.New("an ingest trigger is required")
+
+	case len(nh.acl) == 0:
+		return nil, errors.New("at least one primary must be allowed to send NOTIFY messages")
+
+	default:
+		return nh, nil
+	}
+}
+
+// ServeRequest processes a NOTIFY message. The response is authoritative and echoes the question.
+func (nh *NotifyHandler) ServeRequest(ctx context.Context, logger *zap.Logger, response *dns.Msg, request NotifyRequest) {
+	switch {
+	case !nh.acl.Allows(request.client):
+		logger.Warn("notify denied", zap.Stringer("client", request.client))
+		response.Rcode = dns.RcodeRefused
+
+	case request.qtype != dns.TypeSOA:
+		response.Rcode = dns.RcodeFormatError
+
+	case len(nh.zones) > 0 && !nh.zones[request.zone]:
+		logger.Warn("notify for an unknown zone", zap.String("zone", request.zone))
+		response.Rcode = dns.RcodeNotAuth
+
+	case !nh.trigger.TriggerZone(request.zone):
+		logger.Warn("notify for a zone with no source", zap.String("zone", request.zone))
+		response.Rcode = dns.RcodeNotAuth
+
+	default:
+		logger.Info("notify accepted", zap.String("zone", request.zone), zap.Stringer("client", request.client))
+		response.Authoritative = true
+	}
+}
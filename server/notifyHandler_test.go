@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/zap"
+)
+
+// newTransferChecker creates an unstarted IngestChecker for a TransferIngester that pulls a zone.
+func newTransferChecker(t *testing.T, zone string) *service.IngestChecker {
+	t.Helper()
+	ti, err := service.NewTransferIngester(
+		service.WithTransfers(config.Transfer{Zone: zone, Primary: "127.0.0.1:53"}),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ic, err := service.NewIngestChecker(service.WithIngester(ti))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ic
+}
+
+func TestNotifyHandler(t *testing.T) {
+	acl, err := ParseACL([]string{"192.0.2.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		zones  []string
+		zone   string
+		qtype  dns.RR
+		client string
+		rcode  uint16
+	}{
+		{name: "transfer zone", zone: "example.org.", client: "192.0.2.1", rcode: dns.RcodeSuccess},
+		{name: "transfer zone without a trailing dot", zone: "EXAMPLE.org", client: "192.0.2.1", rcode: dns.RcodeSuccess},
+		{name: "unknown zone", zone: "example.net.", client: "192.0.2.1", rcode: dns.RcodeNotAuth},
+		{name: "restricted zone", zones: []string{"example.net."}, zone: "example.org.", client: "192.0.2.1", rcode: dns.RcodeNotAuth},
+		{name: "restricted zone without a source", zones: []string{"example.net."}, zone: "example.net.", client: "192.0.2.1", rcode: dns.RcodeNotAuth},
+		{name: "denied client", zone: "example.org.", client: "198.51.100.1", rcode: dns.RcodeRefused},
+		{name: "not a SOA", zone: "example.org.", qtype: &dns.A{}, client: "192.0.2.1", rcode: dns.RcodeFormatError},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			nh, err := NewNotifyHandler(
+				WithNotifyTrigger(newTransferChecker(t, "example.org.")),
+				WithNotifyZones(testCase.zones...),
+				WithNotifyACL(acl),
+			)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			question := testCase.qtype
+			if question == nil {
+				question = &dns.SOA{}
+			}
+
+			question.Header().Name, question.Header().Class = testCase.zone, dns.ClassINET
+			client := &net.UDPAddr{IP: net.ParseIP(testCase.client), Port: 53}
+			response := new(dns.Msg)
+			nh.ServeRequest(context.Background(), zap.NewNop(), response, ParseNotifyRequest(question, client))
+			if response.Rcode != testCase.rcode {
+				t.Errorf("expected %s, got %s", dns.RcodeToString[testCase.rcode], dns.RcodeToString[response.Rcode])
+			}
+
+			if authoritative := testCase.rcode == dns.RcodeSuccess; response.Authoritative != authoritative {
+				t.Errorf("expected authoritative to be %t", authoritative)
+			}
+		})
+	}
+}
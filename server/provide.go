@@ -4,9 +4,10 @@
 package server
 
 import (
-	"github.com/xmidt-org/hashy"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/xmidt-org/hashy/config"
 	"github.com/xmidt-org/hashy/service"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
@@ -15,39 +16,75 @@ import (
 func Provide() fx.Option {
 	return fx.Options(
 		fx.Provide(
-			func(zcfg config.Zone, l *zap.Logger) (j *hashy.TTLJitterer, err error) {
-				j, err = hashy.NewTTLJitterer(
-					hashy.DurationToSeconds(zcfg.TTL),
-					zcfg.TTLJitter,
-				)
+			// create a Zone, with its own handlers, for each configured zone
+			func(zcfgs config.Zones, locator *service.Locator, l *zap.Logger) (zones []Zone, err error) {
+				zones = make([]Zone, 0, len(zcfgs))
+				for _, zcfg := range zcfgs {
+					var z Zone
+					if z, err = NewZone(zcfg, locator); err != nil {
+						return
+					}
 
-				if err == nil {
-					lo, hi := j.Range()
+					lo, hi := z.EndpointHandler.jitterer.Range()
 					l.Debug("TTL jitter range for generated DNS RRs",
-						zap.Uint32("lo", lo), zap.Uint32("hi", hi))
+						zap.String("domain", zcfg.Domain), zap.Uint32("lo", lo), zap.Uint32("hi", hi))
+
+					zones = append(zones, z)
 				}
 
 				return
 			},
-			func(locator *service.Locator, jitterer *hashy.TTLJitterer) (*EndpointHandler, error) {
-				return NewEndpointHandler(
-					WithEndpointLocator(locator),
-					WithEndpointJitterer(jitterer),
-				)
+			// create the handler for DNS UPDATE messages, which is nil if no zones can be updated
+			func(ucfg config.Update, overlay *service.Overlay, ic *service.IngestChecker) (uh *UpdateHandler, err error) {
+				if len(ucfg.Zones) == 0 {
+					return
+				}
+
+				var acl ACL
+				if acl, err = ParseACL(ucfg.ACL); err == nil {
+					uh, err = NewUpdateHandler(
+						WithUpdateOverlay(overlay),
+						WithUpdateTrigger(ic),
+						WithUpdateZones(ucfg.Zones...),
+						WithUpdateKeys(ucfg.Keys),
+						WithUpdateACL(acl),
+					)
+				}
+
+				return
 			},
-			func(locator *service.Locator, jitterer *hashy.TTLJitterer) (*GroupHandler, error) {
-				return NewGroupHandler(
-					WithGroupLocator(locator),
-					WithGroupJitterer(jitterer),
-				)
+			// create the handler for NOTIFY messages, which is nil unless some primaries are allowed
+			func(ncfg config.Notify, ic *service.IngestChecker) (nh *NotifyHandler, err error) {
+				if len(ncfg.ACL) == 0 {
+					return
+				}
+
+				var acl ACL
+				if acl, err = ParseACL(ncfg.ACL); err == nil {
+					nh, err = NewNotifyHandler(
+						WithNotifyTrigger(ic),
+						WithNotifyZones(ncfg.Zones...),
+						WithNotifyACL(acl),
+					)
+				}
+
+				return
 			},
 			// create the base handler that will be cloned for each server
-			func(zcfg config.Zone, base *zap.Logger, eh *EndpointHandler, gh *GroupHandler) (*Handler, error) {
+			func(zones []Zone, locator *service.Locator, uh *UpdateHandler, nh *NotifyHandler, base *zap.Logger, r prometheus.Registerer, tp trace.TracerProvider) (*Handler, error) {
+				m, err := NewMetrics(r)
+				if err != nil {
+					return nil, err
+				}
+
 				return NewHandler(
-					WithZoneDomain(zcfg.Domain),
 					WithLogger(base),
-					WithEndpointHandler(eh),
-					WithGroupHandler(gh),
+					WithMetrics(m),
+					WithTracerProvider(tp),
+					WithReadiness(locator),
+					WithUpdate(uh),
+					WithNotify(nh),
+					WithZones(zones...),
 				)
 			},
 			// create the server Bundle and bind it to the fx.App lifecycle
@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// TracerName is the name of the OpenTelemetry tracer for DNS request handling.
+	TracerName = "github.com/xmidt-org/hashy/server"
+)
+
+// span attribute keys for DNS requests
+const (
+	questionNameKey = attribute.Key("dns.question.name")
+	questionTypeKey = attribute.Key("dns.question.type")
+	rcodeKey        = attribute.Key("dns.response.rcode")
+	zoneKey         = attribute.Key("hashy.zone")
+	subdomainKey    = attribute.Key("hashy.subdomain")
+	prefixKey       = attribute.Key("hashy.object.prefix")
+	groupsKey       = attribute.Key("hashy.groups")
+	endpointsKey    = attribute.Key("hashy.endpoints")
+)
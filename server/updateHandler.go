@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/zap"
+)
+
+// UpdateRequest holds an RFC 2136 UPDATE message.
+type UpdateRequest struct {
+	msg    *dns.Msg
+	zone   string
+	zoneRR uint16
+	client netip.Addr
+}
+
+// ParseUpdateRequest extracts the information necessary to process an UPDATE message. The
+// zone is the single RR of the message's zone section, which is the question section for queries.
+func ParseUpdateRequest(msg *dns.Msg, zone dns.RR, client net.Addr) UpdateRequest {
+	return UpdateRequest{
+		msg:    msg,
+		zone:   dnsutil.Canonical(zone.Header().Name),
+		zoneRR: dns.RRToType(zone),
+		client: clientAddr(client),
+	}
+}
+
+// responseTSIG holds what is necessary to sign the response to a TSIG signed request.
+type responseTSIG struct {
+	signer     dns.TSIGSigner
+	name       string
+	algorithm  string
+	requestMAC string
+}
+
+// stub returns the unsigned TSIG RR, which must be the last pseudo RR before the response is packed.
+func (rt *responseTSIG) stub() *dns.TSIG {
+	return dns.NewTSIG(rt.name, rt.algorithm, 0)
+}
+
+// sign signs a packed response.
+func (rt *responseTSIG) sign(response *dns.Msg) error {
+	return dns.TSIGSign(response, rt.signer, &dns.TSIGOption{RequestMAC: rt.requestMAC})
+}
+
+type UpdateHandlerOption interface {
+	applyToUpdateHandler(*UpdateHandler) error
+}
+
+type updateHandlerOptionFunc func(*UpdateHandler) error
+
+func (f updateHandlerOptionFunc) applyToUpdateHandler(uh *UpdateHandler) error { return f(uh) }
+
+// WithUpdateOverlay sets the Overlay that updates are applied to. This option is required.
+func WithUpdateOverlay(o *service.Overlay) UpdateHandlerOption {
+	return updateHandlerOptionFunc(func(uh *UpdateHandler) error {
+		uh.overlay = o
+		return nil
+	})
+}
+
+// WithUpdateTrigger sets what rebuilds groups after each update. This option is required.
+func WithUpdateTrigger(t IngestTrigger) UpdateHandlerOption {
+	return updateHandlerOptionFunc(func(uh *UpdateHandler) error {
+		uh.trigger = t
+		return nil
+	})
+}
+
+// WithUpdateZones adds zones that may be updated.
+func WithUpdateZones(zones ...string) UpdateHandlerOption {
+	return updateHandlerOptionFunc(func(uh *UpdateHandler) error {
+		if uh.zones == nil {
+			uh.zones = make(map[string]bool, len(zones))
+		}
+
+		for _, z := range zones {
+			uh.zones[dnsutil.Canonical(z)] = true
+		}
+
+		return nil
+	})
+}
+
+// WithUpdateKeys adds HMAC TSIG keys, as a map of key names onto base64 encoded secrets.
+func WithUpdateKeys(keys map[string]string) UpdateHandlerOption {
+	return updateHandlerOptionFunc(func(uh *UpdateHandler) error {
+		if uh.keys == nil {
+			uh.keys = make(map[string]dns.HmacTSIG, len(keys))
+		}
+
+		for name, secret := range keys {
+			decoded, err := base64.StdEncoding.DecodeString(secret)
+			if err != nil {
+				return fmt.Errorf("invalid secret for TSIG key %s: %w", name, err)
+			}
+
+			uh.keys[dnsutil.Canonical(name)] = dns.HmacTSIG{Secret: decoded}
+		}
+
+		return nil
+	})
+}
+
+// WithUpdateACL restricts the clients that may send updates. By default, signed
+// updates are accepted from any client.
+func WithUpdateACL(acl ACL) UpdateHandlerOption {
+	return updateHandlerOptionFunc(func(uh *UpdateHandler) error {
+		uh.acl = append(uh.acl, acl...)
+		return nil
+	})
+}
+
+// UpdateHandler processes RFC 2136 UPDATE messages, which add or remove the SRV, A, AAAA,
+// and TXT records that groups are built from. Every update must be signed with a known TSIG
+// key, and every name it changes must be within the zone it names.
+//
+// Accepted updates are applied to an Overlay, after which an ingest is triggered to rebuild
+// groups. The response does not wait for that ingest, so an update is acknowledged once it has
+// been journaled. Prerequisites are not supported.
+type UpdateHandler struct {
+	overlay *service.Overlay
+	trigger IngestTrigger
+	zones   map[string]bool
+	keys    map[string]dns.HmacTSIG
+	acl     ACL
+}
+
+func NewUpdateHandler(opts ...UpdateHandlerOption) (*UpdateHandler, error) {
+	uh := new(UpdateHandler)
+	for _, o := range opts {
+		if err := o.applyToUpdateHandler(uh); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case uh.overlay == nil:
+		return nil, errors.New("an overlay is required")
+
+	case uh.trigger == nil:
+		return nil, errors.New("an ingest trigger is required")
+
+	case len(uh.keys) == 0:
+		return nil, errors.New("at least one TSIG key is required")
+
+	default:
+		return uh, nil
+	}
+}
+
+// verify checks the TSIG of an update. If the update is properly signed, the returned
+// responseTSIG is used to sign the response.
+func (uh *UpdateHandler) verify(request UpdateRequest) (*responseTSIG, error) {
+	var t *dns.TSIG
+	if n := len(request.msg.Pseudo); n > 0 {
+		t, _ = request.msg.Pseudo[n-1].(*dns.TSIG)
+	}
+
+	if t == nil {
+		return nil, errors.New("update is not signed")
+	}
+
+	key, exists := uh.keys[dnsutil.Canonical(t.Hdr.Name)]
+	if !exists {
+		return nil, fmt.Errorf("unknown TSIG key: %s", t.Hdr.Name)
+	}
+
+	var options dns.TSIGOption
+	if err := dns.TSIGVerify(request.msg, key, &options); err != nil {
+		return nil, err
+	}
+
+	return &responseTSIG{
+		signer:     key,
+		name:       t.Hdr.Name,
+		algorithm:  t.Algorithm,
+		requestMAC: options.RequestMAC,
+	}, nil
+}
+
+// ServeRequest processes an UPDATE message. If the request was properly signed, the returned
+// responseTSIG must be used to sign the response.
+func (uh *UpdateHandler) ServeRequest(_ context.Context, logger *zap.Logger, response *dns.Msg, request UpdateRequest) (rt *responseTSIG) {
+	if len(uh.acl) > 0 && !uh.acl.Allows(request.client) {
+		logger.Warn("update denied", zap.Stringer("client", request.client))
+		response.Rcode = dns.RcodeRefused
+		return
+	}
+
+	var err error
+	if rt, err = uh.verify(request); err != nil {
+		logger.Warn("update not authenticated", zap.Error(err))
+		response.Rcode = dns.RcodeNotAuth
+		return
+	}
+
+	switch {
+	case request.zoneRR != dns.TypeSOA:
+		response.Rcode = dns.RcodeFormatError
+		return
+
+	case !uh.zones[request.zone]:
+		logger.Warn("update for an unknown zone", zap.String("zone", request.zone))
+		response.Rcode = dns.RcodeNotAuth
+		return
+
+	case len(request.msg.Answer) > 0:
+		logger.Warn("update prerequisites are not supported")
+		response.Rcode = dns.RcodeNotImplemented
+		return
+	}
+
+	for _, rr := range request.msg.Ns {
+		if !dnsutil.IsBelow(request.zone, rr.Header().Name) {
+			logger.Warn("update outside of zone", zap.String("zone", request.zone), zap.String("name", rr.Header().Name))
+			response.Rcode = dns.RcodeNotZone
+			return
+		}
+	}
+
+	if err = uh.overlay.Update(request.msg.Ns); err != nil {
+		logger.Error("update rejected", zap.Error(err))
+		response.Rcode = dns.RcodeRefused
+		return
+	}
+
+	logger.Info("update applied", zap.String("zone", request.zone), zap.Int("rrs", len(request.msg.Ns)))
+	uh.trigger.Trigger()
+	return
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"github.com/xmidt-org/hashy/service"
+	"go.uber.org/zap"
+)
+
+const (
+	testUpdateKey    = "update.example.org."
+	testUpdateSecret = "c2VjcmV0"
+)
+
+// countingTrigger is an IngestTrigger that counts its triggers.
+type countingTrigger int
+
+func (ct *countingTrigger) Trigger() { *ct++ }
+
+// newTestUpdateRequest creates an UPDATE for a zone, as it would arrive from a client. The
+// prerequisite section is the message's answer section, and the update section is its
+// authority section. The message is signed unless key is empty.
+func newTestUpdateRequest(t *testing.T, zone, key string, prerequisites, updates []string) UpdateRequest {
+	t.Helper()
+	m := dns.NewMsg(zone, dns.TypeSOA)
+	m.Opcode = dns.OpcodeUpdate
+	for _, s := range prerequisites {
+		rr, err := dns.New(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.Answer = append(m.Answer, rr)
+	}
+
+	for _, s := range updates {
+		rr, err := dns.New(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.Ns = append(m.Ns, rr)
+	}
+
+	if len(key) > 0 {
+		secret, _ := base64.StdEncoding.DecodeString(testUpdateSecret)
+		m.Pseudo = []dns.RR{dns.NewTSIG(key, dns.HmacSHA256, 0)}
+		if err := dns.TSIGSign(m, dns.HmacTSIG{Secret: secret}, &dns.TSIGOption{}); err != nil {
+			t.Fatal(err)
+		}
+	} else if err := m.Pack(); err != nil {
+		t.Fatal(err)
+	}
+
+	received := &dns.Msg{Data: m.Data}
+	if err := received.Unpack(); err != nil {
+		t.Fatal(err)
+	}
+
+	return ParseUpdateRequest(received, received.Question[0], &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 53})
+}
+
+func TestUpdateHandler(t *testing.T) {
+	testCases := []struct {
+		name          string
+		zone          string
+		key           string
+		prerequisites []string
+		updates       []string
+		rcode         uint16
+		added         int
+		triggers      int
+	}{
+		{
+			name:     "add",
+			zone:     "example.org.",
+			key:      testUpdateKey,
+			updates:  []string{"e1.example.org. 60 IN A 192.0.2.1"},
+			rcode:    dns.RcodeSuccess,
+			added:    1,
+			triggers: 1,
+		},
+		{
+			name:          "prerequisites",
+			zone:          "example.org.",
+			key:           testUpdateKey,
+			prerequisites: []string{"e1.example.org. 0 IN A 192.0.2.1"},
+			updates:       []string{"e1.example.org. 60 IN A 192.0.2.1"},
+			rcode:         dns.RcodeNotImplemented,
+		},
+		{
+			name:    "unsigned",
+			zone:    "example.org.",
+			updates: []string{"e1.example.org. 60 IN A 192.0.2.1"},
+			rcode:   dns.RcodeNotAuth,
+		},
+		{
+			name:    "unknown key",
+			zone:    "example.org.",
+			key:     "other.example.org.",
+			updates: []string{"e1.example.org. 60 IN A 192.0.2.1"},
+			rcode:   dns.RcodeNotAuth,
+		},
+		{
+			name:    "unknown zone",
+			zone:    "example.net.",
+			key:     testUpdateKey,
+			updates: []string{"e1.example.net. 60 IN A 192.0.2.1"},
+			rcode:   dns.RcodeNotAuth,
+		},
+		{
+			name:    "outside of zone",
+			zone:    "example.org.",
+			key:     testUpdateKey,
+			updates: []string{"e1.example.net. 60 IN A 192.0.2.1"},
+			rcode:   dns.RcodeNotZone,
+		},
+		{
+			name:    "unsupported type",
+			zone:    "example.org.",
+			key:     testUpdateKey,
+			updates: []string{"example.org. 60 IN MX 10 mail.example.org."},
+			rcode:   dns.RcodeRefused,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			overlay, err := service.NewOverlay()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var trigger countingTrigger
+			uh, err := NewUpdateHandler(
+				WithUpdateOverlay(overlay),
+				WithUpdateTrigger(&trigger),
+				WithUpdateZones("example.org."),
+				WithUpdateKeys(map[string]string{testUpdateKey: testUpdateSecret}),
+			)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response := new(dns.Msg)
+			uh.ServeRequest(context.Background(), zap.NewNop(), response, newTestUpdateRequest(t, testCase.zone, testCase.key, testCase.prerequisites, testCase.updates))
+			if response.Rcode != testCase.rcode {
+				t.Errorf("expected %s, got %s", dns.RcodeToString[testCase.rcode], dns.RcodeToString[response.Rcode])
+			}
+
+			added := 0
+			for range overlay.RRs() {
+				added++
+			}
+
+			if added != testCase.added {
+				t.Errorf("expected %d added RRs, got %d", testCase.added, added)
+			}
+
+			if int(trigger) != testCase.triggers {
+				t.Errorf("expected %d triggers, got %d", testCase.triggers, trigger)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/hashy/service"
+)
+
+// Zone describes a single domain served by a Handler, along with the
+// handlers that serve that domain's subdomains.
+type Zone struct {
+	// Domain is the domain of this zone. If unset, DefaultZoneDomain is used.
+	Domain string
+
+	// EndpointLabel is the label beneath Domain that serves endpoint hashes.
+	// If unset, DefaultEndpointLabel is used.
+	EndpointLabel string
+
+	// GroupLabel is the label beneath Domain that serves group metadata.
+	// If unset, DefaultGroupLabel is used.
+	GroupLabel string
+
+	// ExplainLabel is the label beneath Domain that explains how objects were placed.
+	// If unset, DefaultExplainLabel is used.
+	ExplainLabel string
+
+	// EndpointHandler serves hashed responses for this zone. This field is required.
+	EndpointHandler *EndpointHandler
+
+	// GroupHandler serves group metadata for this zone. This field is required.
+	GroupHandler *GroupHandler
+
+	// ExplainHandler explains placements for this zone. If unset, this zone
+	// does not serve explain requests.
+	ExplainHandler *ExplainHandler
+}
+
+// NewZone creates a Zone from configuration. The returned Zone will have its own
+// handlers that share the given locator but use the zone's TTL settings. As before
+// zones were configurable, a zero TTL generates records with a TTL of 0, and a
+// negative TTL is an error.
+func NewZone(zcfg config.Zone, locator *service.Locator) (z Zone, err error) {
+	if zcfg.TTL < 0 {
+		err = fmt.Errorf("zone %s: invalid TTL %s", zcfg.Domain, zcfg.TTL)
+		return
+	}
+
+	var j *hashy.TTLJitterer
+	j, err = hashy.NewTTLJitterer(
+		hashy.DurationToSeconds(zcfg.TTL),
+		zcfg.TTLJitter,
+	)
+
+	if err == nil {
+		z.EndpointHandler, err = NewEndpointHandler(
+			WithEndpointLocator(locator),
+			WithEndpointJitterer(j),
+		)
+	}
+
+	if err == nil {
+		z.GroupHandler, err = NewGroupHandler(
+			WithGroupLocator(locator),
+			WithGroupJitterer(j),
+		)
+	}
+
+	var acl ACL
+	if err == nil {
+		acl, err = ParseACL(zcfg.ExplainACL)
+	}
+
+	if err == nil {
+		z.ExplainHandler, err = NewExplainHandler(
+			WithExplainLocator(locator),
+			WithExplainACL(acl),
+		)
+	}
+
+	if err == nil {
+		z.Domain = zcfg.Domain
+		z.EndpointLabel = zcfg.EndpointLabel
+		z.GroupLabel = zcfg.GroupLabel
+		z.ExplainLabel = zcfg.ExplainLabel
+	}
+
+	return
+}
+
+// zone is the internal, normalized form of a Zone used for routing requests.
+type zone struct {
+	// domain is the fully qualified domain of this zone.
+	domain string
+
+	// endpointDomain is the subdomain the endpoint handler serves.
+	endpointDomain string
+
+	// endpointHandler is the handler that serves hashed responses.
+	endpointHandler *EndpointHandler
+
+	// groupDomain is the subdomain the group handler serves.
+	groupDomain string
+
+	// groupHandler is the handler that serves metadata about groups.
+	groupHandler *GroupHandler
+
+	// explainDomain is the subdomain the explain handler serves.
+	explainDomain string
+
+	// explainHandler is the optional handler that explains placements.
+	explainHandler *ExplainHandler
+}
+
+// newZone validates and normalizes a Zone.
+func newZone(z Zone) (nz zone, err error) {
+	switch {
+	case z.EndpointHandler == nil:
+		err = errors.New("an endpoint handler is required")
+
+	case z.GroupHandler == nil:
+		err = errors.New("a group handler is required")
+
+	default:
+		if len(z.Domain) == 0 {
+			z.Domain = DefaultZoneDomain
+		}
+
+		if len(z.EndpointLabel) == 0 {
+			z.EndpointLabel = DefaultEndpointLabel
+		}
+
+		if len(z.GroupLabel) == 0 {
+			z.GroupLabel = DefaultGroupLabel
+		}
+
+		if len(z.ExplainLabel) == 0 {
+			z.ExplainLabel = DefaultExplainLabel
+		}
+
+		nz = zone{
+			domain:          dnsutil.Fqdn(z.Domain),
+			endpointDomain:  dnsutil.Join(z.EndpointLabel, z.Domain),
+			endpointHandler: z.EndpointHandler,
+			groupDomain:     dnsutil.Join(z.GroupLabel, z.Domain),
+			groupHandler:    z.GroupHandler,
+		}
+
+		if z.ExplainHandler != nil {
+			nz.explainDomain = dnsutil.Join(z.ExplainLabel, z.Domain)
+			nz.explainHandler = z.ExplainHandler
+		}
+
+		if nz.endpointDomain == nz.groupDomain || nz.endpointDomain == nz.explainDomain || nz.groupDomain == nz.explainDomain {
+			err = fmt.Errorf("zone %s: the endpoint, group, and explain labels must be different", nz.domain)
+		}
+	}
+
+	return
+}
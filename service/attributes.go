@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// WeightAttribute is the endpoint attribute that explicitly sets an endpoint's weight.
+	// When present, this attribute overrides the weight of any SRV records that target the endpoint.
+	WeightAttribute = "weight"
+)
+
+// Attributes holds key/value pairs that describe an endpoint or a group. Endpoint attributes
+// are supplied via TXT records owned by the endpoint's name, e.g. "weight=4". Group attributes
+// are supplied as fields in a group definition.
+type Attributes map[string]string
+
+// Get returns the value of an attribute and whether that attribute was present.
+func (a Attributes) Get(key string) (value string, exists bool) {
+	value, exists = a[key]
+	return
+}
+
+// set sets a single attribute, creating this Attributes as needed.
+func (a *Attributes) set(key, value string) {
+	if *a == nil {
+		*a = make(Attributes)
+	}
+
+	(*a)[key] = value
+}
+
+// merge copies all the given attributes into this Attributes, creating it as needed.
+func (a *Attributes) merge(more Attributes) {
+	for key, value := range more {
+		a.set(key, value)
+	}
+}
+
+// validateAttribute checks the values of the attributes that hashy understands.
+func validateAttribute(key, value string) (err error) {
+	switch key {
+	case WeightAttribute:
+		_, err = strconv.ParseUint(value, 10, 16)
+
+	case CapacityAttribute:
+		_, err = strconv.ParseUint(value, 10, 32)
+
+	case DrainAttribute:
+		_, err = strconv.ParseBool(value)
+	}
+
+	return
+}
+
+// addTxt parses whitespace delimited key=value fields from a TXT record and adds them to this
+// set of attributes, creating it as needed. Fields without an '=' are ignored, which allows
+// unrelated TXT records to coexist with attributes.
+//
+// Later values for the same key overwrite earlier values.
+func (a *Attributes) addTxt(txt string) error {
+	for _, field := range strings.Fields(txt) {
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			continue
+		}
+
+		if err := validateAttribute(key, value); err != nil {
+			return fmt.Errorf("invalid attribute [%s]: %s", field, err)
+		}
+
+		a.set(key, value)
+	}
+
+	return nil
+}
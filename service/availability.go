@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+// Availability reports whether endpoints may have objects hashed to them. Endpoints
+// that are not available are left off their group's ring, which moves only their share
+// of the keyspace to other endpoints.
+type Availability interface {
+	// Available tests whether an endpoint may be placed on its group's ring.
+	Available(*Endpoint) bool
+}
+
+// AvailabilityFunc is a function type that implements Availability.
+type AvailabilityFunc func(*Endpoint) bool
+
+func (af AvailabilityFunc) Available(e *Endpoint) bool { return af(e) }
+
+// availabilities is an aggregate Availability. An endpoint is available only if
+// every Availability reports it as available. An empty availabilities reports all
+// endpoints as available.
+type availabilities []Availability
+
+func (as availabilities) Available(e *Endpoint) bool {
+	for _, a := range as {
+		if !a.Available(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bestTier returns the best (lowest) priority among the available endpoints. If no
+// endpoint is available, this function returns false.
+func bestTier(endpoints []*Endpoint, a Availability) (priority uint16, found bool) {
+	for _, e := range endpoints {
+		if (!found || e.Priority() < priority) && a.Available(e) {
+			priority, found = e.Priority(), true
+		}
+	}
+
+	return
+}
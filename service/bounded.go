@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+const (
+	// CapacityAttribute is the endpoint attribute that sets an endpoint's static capacity
+	// for bounded loads. Without this attribute, an endpoint's capacity is its weight.
+	CapacityAttribute = "capacity"
+
+	// BoundedLoadSlots is the number of slots the keyspace of a group is divided into
+	// when bounded loads are enabled. Objects hash to a slot, and each slot is assigned
+	// to an endpoint.
+	BoundedLoadSlots = 4096
+)
+
+// validateLoadFactor checks that a load factor is either zero (disabled) or greater than one.
+func validateLoadFactor(f float64) error {
+	if f != 0.0 && !(f > 1.0) {
+		return fmt.Errorf("invalid load factor %g: must be greater than 1.0", f)
+	}
+
+	return nil
+}
+
+// capacity returns the static capacity of an endpoint for bounded loads.
+func capacity(e *Endpoint) float64 {
+	if v, exists := e.Attributes().Get(CapacityAttribute); exists {
+		// we validated this when the attribute was collected
+		c, _ := strconv.ParseUint(v, 10, 32)
+		return float64(c)
+	}
+
+	return float64(max(e.Weight(), 1))
+}
+
+// boundedPlacement is consistent hashing with bounded loads, applied to a fixed number of
+// keyspace slots rather than to individual objects. Slots are assigned in order to the first
+// endpoint, in the order of preference of the underlying placement, whose count of assigned
+// slots is below its bound. An endpoint's bound is the load factor times its share of the
+// group's capacity.
+//
+// Since the slots are assigned once when a group is placed, lookups are deterministic for a
+// given Groups.
+//
+// The bound is on slots, not on real load. Capacities come only from the static capacity
+// attribute or the weight, and objects are never counted, so an endpoint whose slots hold
+// unusually busy objects is not relieved. Slots are also assigned greedily on every rebuild,
+// including each health change, so a change to one endpoint can reassign a few slots of others.
+type boundedPlacement struct {
+	base  placement
+	slots []*Endpoint
+}
+
+// slotKey returns the key used to find the preferred endpoints of a slot.
+func slotKey(slot int) objectKey {
+	return objectKey{
+		str:      "slot-" + strconv.Itoa(slot),
+		isString: true,
+	}
+}
+
+func newBoundedPlacement(base placement, factor float64) *boundedPlacement {
+	bp := &boundedPlacement{
+		base: base,
+	}
+
+	if base.Len() == 0 {
+		return bp
+	}
+
+	var (
+		endpoints     = make([]*Endpoint, 0, base.Len())
+		totalCapacity float64
+	)
+
+	// discover the endpoints from the base placement, so that bounds are only
+	// computed for endpoints that objects can actually be placed onto
+	for _, le := range base.appendNearest(nil, base.Len(), slotKey(0)) {
+		endpoints = append(endpoints, le.Endpoint)
+		totalCapacity += capacity(le.Endpoint)
+	}
+
+	bounds := make(map[*Endpoint]int, len(endpoints))
+	for _, e := range endpoints {
+		share := 1.0 / float64(len(endpoints))
+		if totalCapacity > 0.0 {
+			share = capacity(e) / totalCapacity
+		}
+
+		bounds[e] = int(math.Ceil(factor * BoundedLoadSlots * share))
+	}
+
+	var (
+		counts = make(map[*Endpoint]int, len(endpoints))
+		prefs  LocatedEndpoints
+	)
+
+	bp.slots = make([]*Endpoint, BoundedLoadSlots)
+	for slot := range bp.slots {
+		// most slots are assigned to their first preference, so only ask the base
+		// placement for more endpoints as needed, doubling the number each time
+		for n := 1; ; n = min(2*n, base.Len()) {
+			prefs = base.appendNearest(prefs[:0], n, slotKey(slot))
+			i := slices.IndexFunc(prefs, func(le LocatedEndpoint) bool {
+				return counts[le.Endpoint] < bounds[le.Endpoint]
+			})
+
+			if i < 0 && n == base.Len() {
+				// every endpoint is at its bound
+				i = len(prefs) - 1
+			}
+
+			if i >= 0 {
+				bp.slots[slot] = prefs[i].Endpoint
+				counts[prefs[i].Endpoint]++
+				break
+			}
+		}
+	}
+
+	return bp
+}
+
+// Len returns the number of endpoints objects can be placed onto.
+func (bp *boundedPlacement) Len() int {
+	return bp.base.Len()
+}
+
+// appendNearest appends the endpoint assigned to the object's slot, followed by
+// the slot's other preferred endpoints.
+func (bp *boundedPlacement) appendNearest(dst LocatedEndpoints, n int, key objectKey) LocatedEndpoints {
+	if bp.Len() == 0 || n < 1 {
+		return dst
+	}
+
+	slot := int(key.hash() % BoundedLoadSlots)
+	assigned := bp.slots[slot]
+	dst = append(dst, LocatedEndpoint{Endpoint: assigned})
+
+	if n > 1 {
+		rank := 1
+		for _, le := range bp.base.appendNearest(nil, n, slotKey(slot)) {
+			if le.Endpoint != assigned && rank < n {
+				dst = append(dst, LocatedEndpoint{
+					Endpoint: le.Endpoint,
+					Rank:     rank,
+				})
+
+				rank++
+			}
+		}
+	}
+
+	return dst
+}
+
+// token returns the bounded load slot the object hashed to, followed by the position
+// that slot matched in the underlying placement.
+func (bp *boundedPlacement) token(key objectKey) string {
+	if bp.Len() == 0 {
+		return ""
+	}
+
+	slot := int(key.hash() % BoundedLoadSlots)
+	return fmt.Sprintf("bounded slot %d/%d %s", slot, BoundedLoadSlots, bp.base.token(slotKey(slot)))
+}
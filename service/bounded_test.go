@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// newBoundedTestPlacement creates a Locator with bounded loads for a group, returning
+// the group's bounded placement.
+func newBoundedTestPlacement(t *testing.T, factor float64, gps *Groups) *boundedPlacement {
+	t.Helper()
+	loc, err := NewLocator(WithLoadFactor(factor))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc.Update(gps)
+	bp, ok := loc.placementsByName["test"].(*boundedPlacement)
+	if !ok {
+		t.Fatal("expected a bounded placement")
+	}
+
+	return bp
+}
+
+func TestBoundedLoads(t *testing.T) {
+	testCases := []struct {
+		name    string
+		weights []uint16
+	}{
+		{name: "equal", weights: []uint16{1, 1, 1, 1, 1}},
+		{name: "weighted", weights: []uint16{4, 2, 1, 1}},
+		{name: "two", weights: []uint16{1, 1}},
+	}
+
+	for _, algorithm := range []Algorithm{AlgorithmRing, AlgorithmRendezvous, AlgorithmJump, AlgorithmMaglev} {
+		for _, factor := range []float64{1.05, 1.25, 2.0} {
+			for _, testCase := range testCases {
+				t.Run(fmt.Sprintf("%s/%g/%s", algorithm, factor, testCase.name), func(t *testing.T) {
+					gps := newWeightedGroups(t, algorithm, testCase.weights...)
+					bp := newBoundedTestPlacement(t, factor, gps)
+
+					var totalCapacity float64
+					for e := range gps.Get("test").Endpoints() {
+						totalCapacity += capacity(e)
+					}
+
+					counts := make(map[*Endpoint]int)
+					for slot, e := range bp.slots {
+						if e == nil {
+							t.Fatalf("slot %d is not assigned", slot)
+						}
+
+						counts[e]++
+					}
+
+					for e, count := range counts {
+						bound := int(math.Ceil(factor * BoundedLoadSlots * capacity(e) / totalCapacity))
+						if count > bound {
+							t.Errorf("%s has %d slots, which is over its bound of %d", e.OriginalName(), count, bound)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestBoundedDeterministic(t *testing.T) {
+	for _, algorithm := range []Algorithm{AlgorithmRing, AlgorithmRendezvous, AlgorithmJump, AlgorithmMaglev} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			// each placement is rebuilt from separately ingested, but identical, groups
+			first := newBoundedTestPlacement(t, 1.25, newWeightedGroups(t, algorithm, 3, 2, 1, 1))
+			second := newBoundedTestPlacement(t, 1.25, newWeightedGroups(t, algorithm, 3, 2, 1, 1))
+			for slot := range first.slots {
+				if first.slots[slot].OriginalName() != second.slots[slot].OriginalName() {
+					t.Fatalf("slot %d moved from %s to %s", slot, first.slots[slot].OriginalName(), second.slots[slot].OriginalName())
+				}
+			}
+
+			for i := range 1000 {
+				key := objectKey{str: fmt.Sprintf("mac:%d", i), isString: true}
+				f, s := first.appendNearest(nil, 2, key), second.appendNearest(nil, 2, key)
+				if len(f) != len(s) {
+					t.Fatalf("expected %d endpoints, got %d", len(f), len(s))
+				}
+
+				for rank := range f {
+					if f[rank].OriginalName() != s[rank].OriginalName() {
+						t.Fatalf("%s moved from %s to %s at rank %d", key.str, f[rank].OriginalName(), s[rank].OriginalName(), rank)
+					}
+				}
+			}
+		})
+	}
+}
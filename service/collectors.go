@@ -7,6 +7,7 @@ import (
 	"net/netip"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"codeberg.org/miekg/dns"
@@ -37,6 +38,7 @@ func (gc *groupCollector) add(gdef GroupDefinition) {
 	merged := (*gc)[gdef.Name]
 	merged.Name = gdef.Name
 	merged.Services = append(merged.Services, gdef.Services...)
+	merged.Attributes.merge(gdef.Attributes)
 	(*gc)[gdef.Name] = merged
 }
 
@@ -93,8 +95,9 @@ func (gc groupCollector) newGroups() *Groups {
 
 	for _, gdef := range gc.sorted() {
 		gps.all = append(gps.all, Group{
-			name:     gdef.Name,
-			services: gdef.Services,
+			name:       gdef.Name,
+			services:   gdef.Services,
+			attributes: gdef.Attributes,
 		})
 	}
 
@@ -105,34 +108,67 @@ func (gc groupCollector) newGroups() *Groups {
 	return gps
 }
 
+// serviceTarget is the target of a single SRV record, along with the information
+// used to select that target.
+type serviceTarget struct {
+	name     string
+	priority uint16
+	weight   uint16
+	port     uint16
+}
+
+// compareServiceTargets orders targets by name.
+func compareServiceTargets(t1, t2 serviceTarget) int {
+	return strings.Compare(t1.name, t2.name)
+}
+
 // servicesCollector collects service->target pairs.
-type servicesCollector map[string]hashy.Values[string]
+type servicesCollector map[string]hashy.Values[serviceTarget]
 
 func (sc servicesCollector) clear() {
 	clear(sc)
 }
 
-func (sc *servicesCollector) add(serviceName, targetName string) {
+func (sc *servicesCollector) add(serviceName string, target serviceTarget) {
 	if *sc == nil {
 		*sc = servicesCollector{
-			serviceName: hashy.Values[string]{targetName},
+			serviceName: hashy.Values[serviceTarget]{target},
 		}
 
 		return
 	}
 
-	(*sc)[serviceName] = (*sc)[serviceName].Append(targetName)
+	(*sc)[serviceName] = (*sc)[serviceName].Append(target)
 }
 
-// targetNames returns a slice of target names that belong to any of the supplied services.
-// The returned slice is deduped and sorted.
-func (sc servicesCollector) targetNames(serviceNames []string) []string {
-	var dedupe hashy.Deduper[string]
+// targets returns a slice of targets that belong to any of the supplied services.
+// The returned slice is deduped by name and sorted. When several SRV records
+// target the same name, the best (lowest) priority and the largest weight are used. The
+// port is taken from the record with the best priority.
+func (sc servicesCollector) targets(serviceNames []string) []serviceTarget {
+	byName := make(map[string]serviceTarget)
 	for _, serviceName := range serviceNames {
-		dedupe.Add(sc[serviceName]...)
+		for _, t := range sc[serviceName] {
+			if existing, exists := byName[t.name]; exists {
+				if existing.priority < t.priority || (existing.priority == t.priority && existing.port < t.port) {
+					t.port = existing.port
+				}
+
+				t.priority = min(t.priority, existing.priority)
+				t.weight = max(t.weight, existing.weight)
+			}
+
+			byName[t.name] = t
+		}
+	}
+
+	targets := make([]serviceTarget, 0, len(byName))
+	for _, t := range byName {
+		targets = append(targets, t)
 	}
 
-	return dedupe.SortFunc(strings.Compare)
+	slices.SortFunc(targets, compareServiceTargets)
+	return targets
 }
 
 // endpointCollector collects information about endpoints (targets).
@@ -178,24 +214,53 @@ func (ec *endpointCollector) addIP6(originalName string, addr netip.Addr) {
 	(*ec)[originalName] = edef
 }
 
+// addTxt parses endpoint attributes from TXT records owned by an endpoint.
+func (ec *endpointCollector) addTxt(originalName string, values ...string) error {
+	if *ec == nil {
+		*ec = make(endpointCollector)
+	}
+
+	edef := (*ec)[originalName]
+	edef.originalName = originalName
+	for _, txt := range values {
+		if err := edef.attributes.addTxt(txt); err != nil {
+			return err
+		}
+	}
+
+	(*ec)[originalName] = edef
+	return nil
+}
+
 // endpointsFor returns a slice of Endpoints corresponding to elements of a slice
-// of target names. The output is 1-1 with the input names. No deduping or sorting is done
-// by this method.
-func (ec endpointCollector) endpointsFor(names []string) []Endpoint {
-	endpoints := make([]Endpoint, 0, len(names))
-	for _, n := range names {
-		if endpoint, exists := ec[n]; exists {
+// of targets. The output is 1-1 with the input targets, except that targets without
+// any addresses are skipped and returned as unresolved. No deduping or sorting is done by this method.
+func (ec endpointCollector) endpointsFor(targets []serviceTarget) (endpoints []Endpoint, unresolved []string) {
+	endpoints = make([]Endpoint, 0, len(targets))
+	for _, t := range targets {
+		if endpoint, exists := ec[t.name]; exists && endpoint.ip4.Len()+endpoint.ip6.Len() > 0 {
 			endpoint.ip4.Dedupe()
 			endpoint.ip4.SortFunc(hashy.CompareAddrs)
 
 			endpoint.ip6.Dedupe()
 			endpoint.ip6.SortFunc(hashy.CompareAddrs)
 
+			endpoint.priority = t.priority
+			endpoint.weight = t.weight
+			endpoint.port = t.port
+			if v, exists := endpoint.attributes.Get(WeightAttribute); exists {
+				// we validated this when the attribute was collected
+				weight, _ := strconv.ParseUint(v, 10, 16)
+				endpoint.weight = uint16(weight)
+			}
+
 			endpoints = append(endpoints, endpoint)
+		} else {
+			unresolved = append(unresolved, t.name)
 		}
 	}
 
-	return endpoints
+	return
 }
 
 // RRCollector collects DNS resource records in order to build a Groups. This type is basically
@@ -212,20 +277,57 @@ type RRCollector struct {
 
 	// endpoints is target (server) -> Endpoint
 	endpoints endpointCollector
+
+	// overlay holds the optional dynamic changes merged with the collected RRs
+	overlay *Overlay
+
+	// hidden holds the RRs that the overlay hid
+	hidden []dns.RR
 }
 
-// AddRR adds an RR to this collector. Any RR that is not recognized is simply ignored.
+// AddRR adds an RR to this collector. Any RR that is not recognized is simply ignored,
+// as is any RR deleted by this collector's Overlay.
 func (rrc *RRCollector) AddRR(rr dns.RR) error {
+	if rrc.overlay != nil && rrc.overlay.hides(rr) {
+		rrc.hidden = append(rrc.hidden, rr)
+		return nil
+	}
+
+	return rrc.addRR(rr)
+}
+
+// addOverlay adds the RRs from this collector's Overlay, if any.
+func (rrc *RRCollector) addOverlay() error {
+	if rrc.overlay != nil {
+		for rr := range rrc.overlay.RRs() {
+			if err := rrc.addRR(rr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addRR adds an RR to this collector without consulting the Overlay.
+func (rrc *RRCollector) addRR(rr dns.RR) error {
 	switch record := rr.(type) {
 	case *dns.TXT:
 		if record.Hdr.Name == rrc.discoveryDomain {
 			if err := rrc.groups.addTxt(record.Txt...); err != nil {
 				return err
 			}
+		} else if err := rrc.endpoints.addTxt(record.Hdr.Name, record.Txt...); err != nil {
+			return err
 		}
 
 	case *dns.SRV:
-		rrc.services.add(record.Hdr.Name, record.Target)
+		rrc.services.add(record.Hdr.Name, serviceTarget{
+			name:     record.Target,
+			priority: record.Priority,
+			weight:   record.Weight,
+			port:     record.Port,
+		})
 
 	case *dns.A:
 		rrc.endpoints.addIP4(record.Hdr.Name, record.Addr)
@@ -250,8 +352,8 @@ func (rrc *RRCollector) Reset() {
 func (rrc *RRCollector) Build() *Groups {
 	gps := rrc.groups.newGroups()
 	for g := range gps.All() {
-		g.endpoints = rrc.endpoints.endpointsFor(
-			rrc.services.targetNames(g.services),
+		g.endpoints, g.unresolved = rrc.endpoints.endpointsFor(
+			rrc.services.targets(g.services),
 		)
 	}
 
@@ -0,0 +1,443 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/medley"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// The types of sources that can be declared in configuration.
+const (
+	SourceTypeZoneFiles = "zoneFiles"
+	SourceTypeDocuments = "documents"
+	SourceTypeTransfer  = "transfer"
+	SourceTypeResolver  = "resolver"
+)
+
+// SourceStatus is the health of a single source of a CompositeIngester.
+type SourceStatus struct {
+	// Name identifies the source.
+	Name string
+
+	// Time is when the source was most recently read.
+	Time time.Time
+
+	// LastSuccess is when the source was most recently read without an error. This is the
+	// zero time if the source has never been read successfully.
+	LastSuccess time.Time
+
+	// Err is the error from the most recent read, if any. A source with an error contributes
+	// the RRs from its most recent successful read.
+	Err error
+
+	// RRs is the number of RRs the source contributes.
+	RRs int
+
+	// FileErrors holds the files the most recent read could not read.
+	FileErrors []FileError
+}
+
+// compositeSource is a single source of a CompositeIngester, along with the outcome of its
+// most recent successful read.
+type compositeSource struct {
+	name     string
+	source   RRSource
+	rrs      []dns.RR
+	checksum uint32
+	status   SourceStatus
+}
+
+// NewRRSource creates the RRSource declared by a source's configuration. Settings that every
+// source shares, such as the discovery domain and zone file origin, come from the groups'
+// configuration.
+func NewRRSource(base *zap.Logger, tp trace.TracerProvider, gcfg config.Groups, scfg config.Source) (RRSource, error) {
+	switch scfg.Type {
+	case SourceTypeZoneFiles:
+		return NewFileIngester(
+			WithIngestLogger(base),
+			WithIngestTracerProvider(tp),
+			WithDiscoveryDomain(gcfg.DiscoveryDomain),
+			WithGlobs(scfg.ZoneFiles...),
+			WithOrigin(gcfg.Origin),
+			WithTTL(gcfg.DefaultTTL),
+			WithFileErrorPolicy(gcfg.FileErrorPolicy),
+			WithRequireFiles(gcfg.RequireFiles),
+		)
+
+	case SourceTypeDocuments:
+		return NewDocumentIngester(
+			WithDocumentLogger(base),
+			WithDocumentTracerProvider(tp),
+			WithDocumentDiscoveryDomain(gcfg.DiscoveryDomain),
+			WithDocuments(scfg.Documents...),
+			WithDocumentTTL(gcfg.DefaultTTL),
+			WithDocumentErrorPolicy(gcfg.FileErrorPolicy),
+			WithDocumentRequireFiles(gcfg.RequireFiles),
+		)
+
+	case SourceTypeTransfer:
+		return NewTransferIngester(
+			WithTransferLogger(base),
+			WithTransferTracerProvider(tp),
+			WithTransferDiscoveryDomain(gcfg.DiscoveryDomain),
+			WithTransfers(scfg.Transfers...),
+		)
+
+	case SourceTypeResolver:
+		return NewResolverIngester(
+			WithResolverLogger(base),
+			WithResolverTracerProvider(tp),
+			WithResolverDiscoveryDomain(gcfg.DiscoveryDomain),
+			WithResolvers(scfg.Resolvers...),
+		)
+
+	default:
+		return nil, fmt.Errorf("unknown source type: %s", scfg.Type)
+	}
+}
+
+type CompositeIngesterOption interface {
+	applyToCompositeIngester(*CompositeIngester) error
+}
+
+type compositeIngesterOptionFunc func(*CompositeIngester) error
+
+func (f compositeIngesterOptionFunc) applyToCompositeIngester(ci *CompositeIngester) error {
+	return f(ci)
+}
+
+func WithCompositeLogger(base *zap.Logger) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		ci.logger = base.Named("compositeIngester")
+		return nil
+	})
+}
+
+// WithSource adds a source. Sources are in order of precedence, so the first source added
+// has the highest precedence. Each source must have a unique name.
+func WithSource(name string, s RRSource) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		if slices.ContainsFunc(ci.sources, func(cs *compositeSource) bool { return cs.name == name }) {
+			return fmt.Errorf("duplicate source: %s", name)
+		}
+
+		ci.sources = append(ci.sources, &compositeSource{
+			name:   name,
+			source: s,
+			status: SourceStatus{Name: name},
+		})
+
+		return nil
+	})
+}
+
+// WithCompositeDiscoveryDomain sets the domain whose TXT records hold group definitions. By default,
+// DefaultDiscoveryDomain is used.
+func WithCompositeDiscoveryDomain(domain string) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		if len(domain) > 0 {
+			ci.discoveryDomain = dnsutil.Fqdn(domain)
+		} else {
+			ci.discoveryDomain = ""
+		}
+
+		return nil
+	})
+}
+
+func WithCompositeListeners(more ...IngestListener) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		ci.listeners = slices.Grow(ci.listeners, len(more))
+		ci.listeners = append(ci.listeners, more...)
+		return nil
+	})
+}
+
+// WithCompositeValidators adds validators that the merged groups from each ingest must pass before
+// they are dispatched. Groups that fail are rejected, and an error event is dispatched instead.
+func WithCompositeValidators(more ...IngestValidator) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		ci.gate.validators = append(ci.gate.validators, more...)
+		return nil
+	})
+}
+
+// WithCompositeMetrics sets the prometheus metrics a CompositeIngester records. By default, no metrics are recorded.
+func WithCompositeMetrics(m *IngestMetrics) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		ci.metrics = m
+		return nil
+	})
+}
+
+// WithCompositeTracerProvider sets the OpenTelemetry provider a CompositeIngester uses to create
+// a span for each ingest, with a child span for each source. By default, no spans are recorded.
+func WithCompositeTracerProvider(tp trace.TracerProvider) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		if tp != nil {
+			ci.tracer = tp.Tracer(TracerName)
+		}
+
+		return nil
+	})
+}
+
+// WithCompositeOverlay sets the Overlay of dynamic changes merged with the records of every source.
+func WithCompositeOverlay(o *Overlay) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) error {
+		ci.overlay = o
+		return nil
+	})
+}
+
+// WithCompositeGroupsConfig applies the settings that a CompositeIngester shares with the other
+// ingesters. Sources must be added separately, with WithSource.
+func WithCompositeGroupsConfig(gcfg config.Groups) CompositeIngesterOption {
+	return compositeIngesterOptionFunc(func(ci *CompositeIngester) (err error) {
+		err = WithCompositeDiscoveryDomain(gcfg.DiscoveryDomain).
+			applyToCompositeIngester(ci)
+
+		if err == nil {
+			err = WithCompositeValidators(NewIngestValidators(gcfg.Validation)...).
+				applyToCompositeIngester(ci)
+		}
+
+		return
+	})
+}
+
+// CompositeIngester merges the RRs of several sources, e.g. zone files for static regions and
+// zone transfers for dynamic ones, into a single set of groups. Sources are in order of precedence:
+// an RRset, identified by owner name and type, only comes from the first source that has it. Group
+// definitions are the exception, since each source may define its own groups, so the discovery
+// domain's TXT records are combined from every source. Each attribute of a group, though, comes
+// from the first source that sets it.
+//
+// Each source's health is tracked separately. A source that fails to read keeps contributing
+// the RRs from its most recent successful read, so one unavailable source does not hold back
+// changes from the others. Until every source has been read successfully, though, ingests fail.
+// A single IngestEvent is dispatched whenever the merged records of any source change.
+type CompositeIngester struct {
+	ingestCore
+
+	// sources holds the state of each source, which is updated by each Ingest while
+	// the ingest lock is held
+	sources []*compositeSource
+}
+
+// NewCompositeIngester creates a CompositeIngester from a set of options. At least one source is required.
+func NewCompositeIngester(opts ...CompositeIngesterOption) (*CompositeIngester, error) {
+	ci := new(CompositeIngester)
+	for _, o := range opts {
+		if err := o.applyToCompositeIngester(ci); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ci.sources) == 0 {
+		return nil, errors.New("at least one source is required")
+	}
+
+	ci.initialize()
+	return ci, nil
+}
+
+// NextIngest returns the soonest time that any source which is an IngestScheduler asks
+// for an ingest, or the zero time if none do.
+func (ci *CompositeIngester) NextIngest() (next time.Time) {
+	for _, cs := range ci.sources {
+		if s, ok := cs.source.(IngestScheduler); ok {
+			if t := s.NextIngest(); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+
+	return
+}
+
+// HasZone tests if any source which is a ZoneIngester pulls a zone.
+func (ci *CompositeIngester) HasZone(zone string) bool {
+	return slices.ContainsFunc(ci.sources, func(cs *compositeSource) bool {
+		zi, ok := cs.source.(ZoneIngester)
+		return ok && zi.HasZone(zone)
+	})
+}
+
+// sourceStatuses returns a copy of the health of each source. The ingest lock must be held.
+func (ci *CompositeIngester) sourceStatuses() []SourceStatus {
+	statuses := make([]SourceStatus, 0, len(ci.sources))
+	for _, cs := range ci.sources {
+		statuses = append(statuses, cs.status)
+	}
+
+	return statuses
+}
+
+// read reads a single source, updating its health. If the read fails, the source
+// keeps the RRs and checksum from its most recent successful read.
+func (ci *CompositeIngester) read(ctx context.Context, cs *compositeSource) (src SourceRRs, err error) {
+	ctx, span := ci.tracer.Start(ctx, "ingest.source", trace.WithAttributes(
+		attribute.String("hashy.source", cs.name),
+	))
+
+	defer span.End()
+	checksummer := ci.checksummer()
+	src, err = cs.source.ReadRRs(ctx, checksummer)
+
+	cs.status.Time = time.Now()
+	cs.status.Err = err
+	cs.status.FileErrors = src.FileErrors
+	if err == nil {
+		cs.rrs = src.RRs
+		cs.checksum = checksummer.Value()
+		cs.status.LastSuccess = cs.status.Time
+		cs.status.RRs = len(cs.rrs)
+	} else {
+		ci.logger.Error("unable to read source", zap.String("source", cs.name), zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.SetAttributes(attribute.Int("hashy.rrs", len(cs.rrs)))
+	return
+}
+
+// groupAttribute identifies a single attribute of a group definition.
+type groupAttribute struct {
+	group string
+	key   string
+}
+
+// definitions returns a discovery domain TXT record without the group attributes that a source with
+// higher precedence already set. The first source to set an attribute of a group keeps it. Definitions
+// left with only a group name are dropped, and nil is returned if no definitions remain.
+func definitions(cs *compositeSource, txt *dns.TXT, owners map[groupAttribute]*compositeSource) dns.RR {
+	kept := make([]string, 0, len(txt.Txt))
+	changed := false
+	for _, def := range txt.Txt {
+		fields := strings.Fields(def)
+		if len(fields) < 2 {
+			// sources validate their own definitions, so this is left for the collector to reject
+			kept = append(kept, def)
+			continue
+		}
+
+		owned := fields[:1]
+		for _, field := range fields[1:] {
+			if key, _, found := strings.Cut(field, "="); found {
+				ga := groupAttribute{group: fields[0], key: key}
+				if owner, exists := owners[ga]; !exists {
+					owners[ga] = cs
+				} else if owner != cs {
+					changed = true
+					continue
+				}
+			}
+
+			owned = append(owned, field)
+		}
+
+		if len(owned) > 1 {
+			kept = append(kept, strings.Join(owned, " "))
+		}
+	}
+
+	switch {
+	case len(kept) == 0:
+		return nil
+
+	case !changed:
+		return txt
+
+	default:
+		clone := txt.Clone().(*dns.TXT)
+		clone.Txt = kept
+		return clone
+	}
+}
+
+// merge returns the RRs of every source, in order of precedence. The ingest lock must be held.
+func (ci *CompositeIngester) merge() (rrs []dns.RR) {
+	discoveryDomain := dnsutil.Canonical(ci.discoveryDomain)
+	owners := make(map[rrsetKey]*compositeSource)
+	attributeOwners := make(map[groupAttribute]*compositeSource)
+	for _, cs := range ci.sources {
+		for _, rr := range cs.rrs {
+			key := rrsetKey{
+				name:   dnsutil.Canonical(rr.Header().Name),
+				rrType: dns.RRToType(rr),
+			}
+
+			if txt, ok := rr.(*dns.TXT); ok && key.name == discoveryDomain {
+				// each source may define groups, but only the first to set an attribute keeps it
+				if rr = definitions(cs, txt, attributeOwners); rr == nil {
+					continue
+				}
+			} else if owner, exists := owners[key]; !exists {
+				owners[key] = cs
+			} else if owner != cs {
+				// a source with higher precedence has this RRset
+				continue
+			}
+
+			rrs = append(rrs, rr)
+		}
+	}
+
+	return
+}
+
+// readSources reads every source and merges their RRs. The ingest lock must be held.
+func (ci *CompositeIngester) readSources(ctx context.Context, checksummer medley.Hash[uint32]) (merged SourceRRs, err error) {
+	for _, cs := range ci.sources {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+
+		src, readErr := ci.read(ctx, cs)
+		merged.Files += src.Files
+		merged.FileErrors = append(merged.FileErrors, src.FileErrors...)
+		if readErr != nil && cs.status.LastSuccess.IsZero() {
+			// without a successful read, this source's groups would simply be missing
+			err = errors.Join(err, fmt.Errorf("source %s: %w", cs.name, readErr))
+		}
+
+		// each source changes exactly when its own checksum does
+		checksummer.Write(binary.BigEndian.AppendUint32(nil, cs.checksum))
+	}
+
+	merged.Sources = ci.sourceStatuses()
+	if err == nil {
+		merged.RRs = ci.merge()
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("hashy.sources", len(ci.sources)))
+	return
+}
+
+// Ingest reads every source and merges their RRs. An IngestEvent is only dispatched if either
+// (a) this is the first Ingest, or (b) the records of any source or the overlay changed.
+func (ci *CompositeIngester) Ingest(ctx context.Context) {
+	ci.ingest(ctx, ci.readSources)
+}
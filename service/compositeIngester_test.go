@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"github.com/xmidt-org/medley"
+)
+
+// testSource is an RRSource that reads a fixed set of RRs, or fails with an error.
+type testSource struct {
+	rrs []dns.RR
+	err error
+}
+
+func (ts *testSource) ReadRRs(_ context.Context, checksummer medley.Hash[uint32]) (SourceRRs, error) {
+	if ts.err != nil {
+		return SourceRRs{}, ts.err
+	}
+
+	for _, rr := range ts.rrs {
+		io.WriteString(checksummer, rr.String())
+	}
+
+	return SourceRRs{RRs: slices.Clone(ts.rrs)}, nil
+}
+
+// newTestSource creates a testSource from RRs in zone file format.
+func newTestSource(t *testing.T, rrs ...string) *testSource {
+	ts := new(testSource)
+	for _, s := range rrs {
+		ts.rrs = append(ts.rrs, newTestRR(t, s))
+	}
+
+	return ts
+}
+
+// groupAddrs returns the addresses of the endpoints of a group, by endpoint name.
+func groupAddrs(g *Group) map[string][]string {
+	addrs := make(map[string][]string)
+	for e := range g.Endpoints() {
+		for addr := range e.Addrs() {
+			addrs[e.OriginalName()] = append(addrs[e.OriginalName()], addr.String())
+		}
+	}
+
+	return addrs
+}
+
+// newTestComposite creates a CompositeIngester that reports to a lastIngest, with sources
+// named in order of precedence.
+func newTestComposite(t *testing.T, sources ...RRSource) (*CompositeIngester, *lastIngest) {
+	t.Helper()
+	last := new(lastIngest)
+	opts := []CompositeIngesterOption{WithCompositeListeners(last)}
+	for i, s := range sources {
+		opts = append(opts, WithSource(string(rune('a'+i)), s))
+	}
+
+	ci, err := NewCompositeIngester(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ci, last
+}
+
+func TestCompositeIngesterPrecedence(t *testing.T) {
+	first := newTestSource(t,
+		`_hashy.discover. 60 IN TXT "test _test._tcp.example.org."`,
+		"_test._tcp.example.org. 60 IN SRV 0 1 8080 e1.example.org.",
+		"e1.example.org. 60 IN A 192.0.2.1",
+	)
+
+	second := newTestSource(t,
+		"_test._tcp.example.org. 60 IN SRV 0 1 8080 e2.example.org.",
+		"e1.example.org. 60 IN A 198.51.100.1",
+		"e1.example.org. 60 IN AAAA 2001:db8::1",
+		"e2.example.org. 60 IN A 192.0.2.2",
+	)
+
+	ci, last := newTestComposite(t, first, second)
+	ci.Ingest(context.Background())
+	if last.event.Err != nil {
+		t.Fatal(last.event.Err)
+	}
+
+	// the first source has the SRV and A RRsets, so only the second's AAAA RRset is merged
+	g := last.event.Groups.Get("test")
+	if g == nil {
+		t.Fatal("expected the test group")
+	}
+
+	if addrs := groupAddrs(g); len(addrs) != 1 || !slices.Equal(addrs["e1.example.org."], []string{"192.0.2.1", "2001:db8::1"}) {
+		t.Errorf("unexpected endpoints: %v", addrs)
+	}
+
+	// a source that fails keeps contributing the RRs of its last successful read
+	second.err = errors.New("unavailable")
+	first.rrs = first.rrs[:2]
+	ci.Ingest(context.Background())
+	if last.event.Err != nil {
+		t.Fatal(last.event.Err)
+	}
+
+	if addrs := groupAddrs(last.event.Groups.Get("test")); !slices.Equal(addrs["e1.example.org."], []string{"198.51.100.1", "2001:db8::1"}) {
+		t.Errorf("unexpected endpoints: %v", addrs)
+	}
+}
+
+func TestCompositeIngesterUnread(t *testing.T) {
+	ci, last := newTestComposite(t,
+		newTestSource(t, `_hashy.discover. 60 IN TXT "test _test._tcp.example.org."`),
+		&testSource{err: errors.New("unavailable")},
+	)
+
+	ci.Ingest(context.Background())
+	if last.event.Err == nil {
+		t.Error("expected an error until every source has been read")
+	}
+}
+
+func TestCompositeIngesterDefinitions(t *testing.T) {
+	ci, last := newTestComposite(t,
+		newTestSource(t,
+			`_hashy.discover. 60 IN TXT "first _first._tcp.example.org. algorithm=jump owner=first"`,
+			"_first._tcp.example.org. 60 IN SRV 0 1 8080 e1.example.org.",
+			"e1.example.org. 60 IN A 192.0.2.1",
+		),
+		newTestSource(t,
+			`_hashy.discover. 60 IN TXT "first _second._tcp.example.org. algorithm=rendezvous region=east" "second _second._tcp.example.org. owner=second"`,
+			"_second._tcp.example.org. 60 IN SRV 0 1 8080 e2.example.org.",
+			"e2.example.org. 60 IN A 192.0.2.2",
+		),
+	)
+
+	ci.Ingest(context.Background())
+	if last.event.Err != nil {
+		t.Fatal(last.event.Err)
+	}
+
+	first := last.event.Groups.Get("first")
+	if first == nil {
+		t.Fatal("expected the first group")
+	}
+
+	// both sources contribute services, but the first source to set an attribute keeps it
+	if services := slices.Sorted(first.Services()); !slices.Equal(services, []string{"_first._tcp.example.org.", "_second._tcp.example.org."}) {
+		t.Errorf("unexpected services: %v", services)
+	}
+
+	for key, expected := range map[string]string{AlgorithmAttribute: "jump", "owner": "first", "region": "east"} {
+		if value, _ := first.Attributes().Get(key); value != expected {
+			t.Errorf("expected %s=%s, got %s", key, expected, value)
+		}
+	}
+
+	if second := last.event.Groups.Get("second"); second == nil {
+		t.Error("expected the second group")
+	} else if value, _ := second.Attributes().Get("owner"); value != "second" {
+		t.Errorf("expected owner=second, got %s", value)
+	}
+}
@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"codeberg.org/miekg/dns/rdata"
+	"github.com/xmidt-org/hashy"
+	"go.yaml.in/yaml/v3"
+)
+
+// GroupsDocument is a declarative description of groups, which is an alternative to hand-writing
+// the TXT, SRV, A, and AAAA records of zone files. A document is YAML or JSON, and it produces
+// exactly the same groups as the equivalent zone files.
+type GroupsDocument struct {
+	Groups []DocumentGroup `json:"groups" yaml:"groups"`
+}
+
+// DocumentGroup is a single group within a GroupsDocument.
+type DocumentGroup struct {
+	Name       string            `json:"name" yaml:"name"`
+	Attributes Attributes        `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Services   []DocumentService `json:"services" yaml:"services"`
+}
+
+// DocumentService is a service, i.e. a set of SRV records, that holds the endpoints of a group.
+type DocumentService struct {
+	Name      string             `json:"name" yaml:"name"`
+	Endpoints []DocumentEndpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// DocumentEndpoint is the target of a single SRV record, along with its addresses and attributes.
+type DocumentEndpoint struct {
+	Name       string       `json:"name" yaml:"name"`
+	Addresses  []netip.Addr `json:"addresses,omitempty" yaml:"addresses,omitempty"`
+	Port       uint16       `json:"port" yaml:"port"`
+	Weight     uint16       `json:"weight" yaml:"weight"`
+	Priority   uint16       `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Attributes Attributes   `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// ParseGroupsDocument parses a YAML or JSON document. Unknown fields are errors, since
+// they are most likely typos.
+func ParseGroupsDocument(data []byte) (doc GroupsDocument, err error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&doc); errors.Is(err, io.EOF) {
+		// an empty document simply has no groups
+		err = nil
+	}
+
+	return
+}
+
+// attributesTxt returns the TXT representation of a set of attributes, with keys in sorted order.
+func attributesTxt(a Attributes) (string, error) {
+	fields := make([]string, 0, len(a))
+	for _, key := range slices.Sorted(maps.Keys(a)) {
+		value := a[key]
+		if len(key) == 0 || strings.ContainsAny(key, "= \t\n") || strings.ContainsAny(value, " \t\n") {
+			return "", fmt.Errorf("invalid attribute [%s=%s]", key, value)
+		}
+
+		fields = append(fields, key+"="+value)
+	}
+
+	return strings.Join(fields, " "), nil
+}
+
+// RRs produces the records equivalent to this document, using the given discovery domain and TTL.
+// Each group becomes a TXT record in the discovery domain, each endpoint of a service becomes an
+// SRV record, and each endpoint's addresses and attributes become A, AAAA, and TXT records. Records
+// that several services or groups share are only produced once.
+func (doc GroupsDocument) RRs(discoveryDomain string, ttl uint32) (rrs []dns.RR, err error) {
+	discoveryDomain = dnsutil.Fqdn(discoveryDomain)
+	seen := make(map[string]bool)
+	add := func(rr dns.RR) {
+		rr.Header().Class = dns.ClassINET
+		rr.Header().TTL = ttl
+		if key := rr.String(); !seen[key] {
+			seen[key] = true
+			rrs = append(rrs, rr)
+		}
+	}
+
+	for _, g := range doc.Groups {
+		definition := []string{g.Name}
+		for _, s := range g.Services {
+			definition = append(definition, dnsutil.Fqdn(s.Name))
+		}
+
+		var txt string
+		if txt, err = attributesTxt(g.Attributes); err != nil {
+			return nil, fmt.Errorf("group %s: %w", g.Name, err)
+		} else if len(txt) > 0 {
+			definition = append(definition, txt)
+		}
+
+		add(&dns.TXT{
+			Hdr: dns.Header{Name: discoveryDomain},
+			TXT: rdata.TXT{Txt: []string{strings.Join(definition, " ")}},
+		})
+	}
+
+	for _, g := range doc.Groups {
+		for _, s := range g.Services {
+			for _, e := range s.Endpoints {
+				target := dnsutil.Fqdn(e.Name)
+				add(&dns.SRV{
+					Hdr: dns.Header{Name: dnsutil.Fqdn(s.Name)},
+					SRV: rdata.SRV{Priority: e.Priority, Weight: e.Weight, Port: e.Port, Target: target},
+				})
+
+				for _, addr := range e.Addresses {
+					if addr.Is4() {
+						add(&dns.A{Hdr: dns.Header{Name: target}, A: rdata.A{Addr: addr}})
+					} else {
+						add(&dns.AAAA{Hdr: dns.Header{Name: target}, AAAA: rdata.AAAA{Addr: addr}})
+					}
+				}
+
+				var txt string
+				if txt, err = attributesTxt(e.Attributes); err != nil {
+					return nil, fmt.Errorf("endpoint %s: %w", e.Name, err)
+				} else if len(txt) > 0 {
+					add(&dns.TXT{Hdr: dns.Header{Name: target}, TXT: rdata.TXT{Txt: []string{txt}}})
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// NewGroupsDocument creates the document equivalent to a set of records, such as those read from
+// zone files. Records that do not affect groups are ignored, and the groups, services, and
+// endpoints of the document are sorted.
+func NewGroupsDocument(rrs []dns.RR, discoveryDomain string) (doc GroupsDocument, err error) {
+	rrc := RRCollector{
+		discoveryDomain: dnsutil.Fqdn(discoveryDomain),
+	}
+
+	for _, rr := range rrs {
+		if err = rrc.addRR(rr); err != nil {
+			return
+		}
+	}
+
+	for _, gdef := range rrc.groups.sorted() {
+		g := DocumentGroup{
+			Name:       gdef.Name,
+			Attributes: gdef.Attributes,
+		}
+
+		for _, serviceName := range gdef.Services {
+			s := DocumentService{
+				Name: serviceName,
+			}
+
+			targets := slices.Clone(rrc.services[serviceName])
+			slices.SortFunc(targets, func(t1, t2 serviceTarget) int {
+				return cmp.Or(
+					compareServiceTargets(t1, t2),
+					cmp.Compare(t1.priority, t2.priority),
+					cmp.Compare(t1.weight, t2.weight),
+					cmp.Compare(t1.port, t2.port),
+				)
+			})
+
+			targets = slices.Compact(targets)
+			for _, t := range targets {
+				e := DocumentEndpoint{
+					Name:     t.name,
+					Port:     t.port,
+					Weight:   t.weight,
+					Priority: t.priority,
+				}
+
+				if endpoint, exists := rrc.endpoints[t.name]; exists {
+					addrs := slices.Concat(endpoint.ip4, endpoint.ip6)
+					addrs.SortFunc(hashy.CompareAddrs)
+					e.Addresses = slices.Compact(addrs)
+					e.Attributes = endpoint.attributes
+				}
+
+				s.Endpoints = append(s.Endpoints, e)
+			}
+
+			g.Services = append(g.Services, s)
+		}
+
+		doc.Groups = append(doc.Groups, g)
+	}
+
+	return
+}
@@ -0,0 +1,319 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/medley"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// errNoDocuments indicates that none of a DocumentIngester's globs matched any files. Like
+// errNoZoneFiles, this is only an error when files are required.
+var errNoDocuments = errors.New("no group documents matched")
+
+type DocumentIngesterOption interface {
+	applyToDocumentIngester(*DocumentIngester) error
+}
+
+type documentIngesterOptionFunc func(*DocumentIngester) error
+
+func (f documentIngesterOptionFunc) applyToDocumentIngester(di *DocumentIngester) error { return f(di) }
+
+func WithDocumentLogger(base *zap.Logger) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		di.logger = base.Named("documentIngester")
+		return nil
+	})
+}
+
+// WithDocuments adds patterns for group documents, using the same syntax as WithGlobs.
+func WithDocuments(more ...string) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.globs = slices.Grow(di.globs, len(more))
+		for _, m := range more {
+			di.globs = append(di.globs, os.ExpandEnv(m))
+		}
+
+		return nil
+	})
+}
+
+// WithDocumentTTL sets the TTL of the records produced from documents.
+func WithDocumentTTL(ttl time.Duration) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.ttl = hashy.DurationToSeconds(ttl)
+		return nil
+	})
+}
+
+func WithDocumentDiscoveryDomain(domain string) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		if len(domain) > 0 {
+			di.discoveryDomain = dnsutil.Fqdn(domain)
+		} else {
+			di.discoveryDomain = ""
+		}
+
+		return nil
+	})
+}
+
+func WithDocumentListeners(more ...IngestListener) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.listeners = slices.Grow(di.listeners, len(more))
+		di.listeners = append(di.listeners, more...)
+		return nil
+	})
+}
+
+// WithDocumentValidators adds validators that the groups from each ingest must pass before they are dispatched.
+func WithDocumentValidators(more ...IngestValidator) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.gate.validators = append(di.gate.validators, more...)
+		return nil
+	})
+}
+
+// WithDocumentMetrics sets the prometheus metrics a DocumentIngester records. By default, no metrics are recorded.
+func WithDocumentMetrics(m *IngestMetrics) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.metrics = m
+		return nil
+	})
+}
+
+// WithDocumentTracerProvider sets the OpenTelemetry provider a DocumentIngester uses to create
+// a span for each ingest, with a child span for each document. By default, no spans are recorded.
+func WithDocumentTracerProvider(tp trace.TracerProvider) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		if tp != nil {
+			di.tracer = tp.Tracer(TracerName)
+		}
+
+		return nil
+	})
+}
+
+// WithDocumentOverlay sets the Overlay of dynamic changes merged with the ingested documents.
+func WithDocumentOverlay(o *Overlay) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.overlay = o
+		return nil
+	})
+}
+
+// WithDocumentErrorPolicy sets what happens when a document cannot be ingested. If unset,
+// FailOnFileError is used.
+func WithDocumentErrorPolicy(p string) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		switch FileErrorPolicy(p) {
+		case "":
+			di.policy = FailOnFileError
+
+		case FailOnFileError, SkipOnFileError:
+			di.policy = FileErrorPolicy(p)
+
+		default:
+			return fmt.Errorf("unknown file error policy: %s", p)
+		}
+
+		return nil
+	})
+}
+
+// WithDocumentRequireFiles sets whether an ingest fails when the globs match no documents at all.
+// By default, globs that match nothing produce empty groups.
+func WithDocumentRequireFiles(v bool) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) error {
+		di.requireFiles = v
+		return nil
+	})
+}
+
+func WithDocumentGroupsConfig(gcfg config.Groups) DocumentIngesterOption {
+	return documentIngesterOptionFunc(func(di *DocumentIngester) (err error) {
+		err = WithDocumentDiscoveryDomain(gcfg.DiscoveryDomain).
+			applyToDocumentIngester(di)
+
+		if err == nil {
+			err = WithDocuments(gcfg.Documents...).
+				applyToDocumentIngester(di)
+		}
+
+		if err == nil {
+			err = WithDocumentTTL(gcfg.DefaultTTL).
+				applyToDocumentIngester(di)
+		}
+
+		if err == nil {
+			err = WithDocumentErrorPolicy(gcfg.FileErrorPolicy).
+				applyToDocumentIngester(di)
+		}
+
+		if err == nil {
+			err = WithDocumentRequireFiles(gcfg.RequireFiles).
+				applyToDocumentIngester(di)
+		}
+
+		if err == nil {
+			err = WithDocumentValidators(NewIngestValidators(gcfg.Validation)...).
+				applyToDocumentIngester(di)
+		}
+
+		return
+	})
+}
+
+// DocumentIngester reads groups from YAML or JSON documents. Each document is converted into
+// the equivalent DNS records, so documents produce exactly the same groups as zone files.
+type DocumentIngester struct {
+	ingestCore
+
+	globs        []string
+	patterns     zoneGlobs
+	ttl          uint32
+	policy       FileErrorPolicy
+	requireFiles bool
+}
+
+// NewDocumentIngester creates a DocumentIngester from a set of options.
+func NewDocumentIngester(opts ...DocumentIngesterOption) (*DocumentIngester, error) {
+	di := new(DocumentIngester)
+	for _, o := range opts {
+		if err := o.applyToDocumentIngester(di); err != nil {
+			return nil, err
+		}
+	}
+
+	di.initialize()
+	var err error
+	if di.patterns, err = newZoneGlobs(di.globs...); err != nil {
+		return nil, err
+	}
+
+	if len(di.policy) == 0 {
+		di.policy = FailOnFileError
+	}
+
+	if di.ttl == 0 {
+		di.ttl = hashy.DurationToSeconds(DefaultFileIngesterTTL)
+	}
+
+	return di, nil
+}
+
+// ingestDocument reads a single document and converts it into RRs. As with zone files,
+// the RRs are only returned if all of them are valid.
+func (di *DocumentIngester) ingestDocument(ctx context.Context, checksummer medley.Hash[uint32], path string) (rrs []dns.RR, err error) {
+	_, span := di.tracer.Start(ctx, "ingest.document", trace.WithAttributes(
+		attribute.String("file.path", path),
+	))
+
+	defer func() {
+		span.SetAttributes(attribute.Int("hashy.rrs", len(rrs)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	checksummer.Write(data)
+	var doc GroupsDocument
+	if doc, err = ParseGroupsDocument(data); err == nil {
+		rrs, err = doc.RRs(di.discoveryDomain, di.ttl)
+	}
+
+	if err != nil {
+		rrs = nil
+		err = fmt.Errorf("%s: %w", path, err)
+		return
+	}
+
+	validator := RRCollector{
+		discoveryDomain: di.discoveryDomain,
+	}
+
+	for _, rr := range rrs {
+		if err = validator.addRR(rr); err != nil {
+			rrs = nil
+			err = fmt.Errorf("%s: %w", path, err)
+			return
+		}
+	}
+
+	return
+}
+
+// ReadRRs reads the RRs equivalent to every document, following this DocumentIngester's
+// FileErrorPolicy. The contents of every document, including skipped documents, are
+// written to the checksummer.
+func (di *DocumentIngester) ReadRRs(ctx context.Context, checksummer medley.Hash[uint32]) (src SourceRRs, err error) {
+	for path, globErr := range di.patterns.files {
+		if err = globErr; err != nil {
+			di.logger.Error("failed to expand document globs", zap.Error(err))
+			return
+		}
+
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		src.Files++
+		ingestLogger := di.logger.With(zap.String("path", path))
+		ingestLogger.Debug("reading document")
+
+		var rrs []dns.RR
+		if rrs, err = di.ingestDocument(ctx, checksummer, path); err != nil {
+			src.FileErrors = append(src.FileErrors, newFileError(path, err))
+			if di.policy != SkipOnFileError {
+				ingestLogger.Error("error reading document", zap.Error(err))
+				return
+			}
+
+			ingestLogger.Error("skipping document", zap.Error(err))
+			err = nil
+		}
+
+		src.RRs = append(src.RRs, rrs...)
+	}
+
+	if src.Files == 0 && len(di.globs) > 0 && di.requireFiles {
+		err = errNoDocuments
+		di.logger.Error("no group documents matched", zap.Strings("globs", di.globs))
+	}
+
+	return
+}
+
+// Ingest reads in all the documents this DocumentIngester was configured with. Just as
+// with a FileIngester, an IngestEvent is only dispatched on the first Ingest or when any
+// document has changed.
+func (di *DocumentIngester) Ingest(ctx context.Context) {
+	di.ingest(ctx, di.ReadRRs)
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"hash/adler32"
+	"reflect"
+	"slices"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+	"github.com/xmidt-org/medley"
+	"go.yaml.in/yaml/v3"
+)
+
+// isDocumentRR tests if an RR is one of the types that a GroupsDocument describes.
+func isDocumentRR(rr dns.RR) bool {
+	switch rr.(type) {
+	case *dns.A, *dns.AAAA, *dns.SRV, *dns.TXT:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// containsRR tests if rrs has an RR equal to rr, ignoring TTLs.
+func containsRR(rrs []dns.RR, rr dns.RR) bool {
+	return slices.ContainsFunc(rrs, func(candidate dns.RR) bool {
+		return dns.Equal(candidate, rr)
+	})
+}
+
+func TestGroupsDocumentRoundTrip(t *testing.T) {
+	fi, err := NewFileIngester(WithGlobs("../sample/*.zone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := fi.ReadRRs(context.Background(), medley.AsConstructor32(adler32.New)())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := NewGroupsDocument(src.RRs, DefaultDiscoveryDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseGroupsDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(doc, parsed) {
+		t.Fatalf("the document changed after YAML:\n%s", data)
+	}
+
+	rrs, err := parsed.RRs(DefaultDiscoveryDomain, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rr := range src.RRs {
+		if isDocumentRR(rr) && !containsRR(rrs, rr) {
+			t.Errorf("the document did not produce %s", rr)
+		}
+	}
+
+	for _, rr := range rrs {
+		if !containsRR(src.RRs, rr) {
+			t.Errorf("the document produced %s, which is not in the sample zones", rr)
+		}
+	}
+
+	again, err := NewGroupsDocument(rrs, DefaultDiscoveryDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(doc, again) {
+		t.Error("the document changed after a round trip through zone records")
+	}
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import "strconv"
+
+const (
+	// DrainAttribute is the endpoint attribute that puts an endpoint into maintenance, e.g. "drain=true".
+	// A draining endpoint keeps its place in its group, so devices already connected to it still
+	// hash to it, but new devices are assigned to its successors.
+	DrainAttribute = "drain"
+)
+
+// drainingAttribute tests whether an endpoint's attributes put it into maintenance.
+func drainingAttribute(e *Endpoint) bool {
+	if v, exists := e.Attributes().Get(DrainAttribute); exists {
+		// we validated this when the attribute was collected
+		draining, _ := strconv.ParseBool(v)
+		return draining
+	}
+
+	return false
+}
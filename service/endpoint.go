@@ -4,6 +4,7 @@
 package service
 
 import (
+	"iter"
 	"net/netip"
 
 	"github.com/xmidt-org/hashy"
@@ -12,6 +13,10 @@ import (
 // Endpoint is a single endpoint of a service.
 type Endpoint struct {
 	originalName string
+	priority     uint16
+	weight       uint16
+	port         uint16
+	attributes   Attributes
 
 	ip4 hashy.Values[netip.Addr]
 	ip6 hashy.Values[netip.Addr]
@@ -22,3 +27,47 @@ type Endpoint struct {
 func (s *Endpoint) OriginalName() string {
 	return s.originalName
 }
+
+// Port is the SRV port of this endpoint. When several SRV records target this endpoint,
+// the port of the record with the best priority is used.
+func (s *Endpoint) Port() uint16 {
+	return s.port
+}
+
+// Addrs returns a sequence of this endpoint's addresses. The IPv4 addresses are
+// returned first, followed by the IPv6 addresses.
+func (s *Endpoint) Addrs() iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		for _, addr := range s.ip4 {
+			if !yield(addr) {
+				return
+			}
+		}
+
+		for _, addr := range s.ip6 {
+			if !yield(addr) {
+				return
+			}
+		}
+	}
+}
+
+// Priority is the SRV priority of this endpoint. Lower values are preferred. Only the
+// endpoints with the best priority that are available are placed on a group's ring.
+// When several SRV records target this endpoint, the best priority is used.
+func (s *Endpoint) Priority() uint16 {
+	return s.priority
+}
+
+// Weight is the relative share of the keyspace this endpoint receives within its group.
+// This is either the WeightAttribute, if present, or the largest weight of the SRV records
+// that target this endpoint.
+func (s *Endpoint) Weight() uint16 {
+	return s.weight
+}
+
+// Attributes returns the attributes of this endpoint. The returned Attributes must
+// not be modified.
+func (s *Endpoint) Attributes() Attributes {
+	return s.attributes
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+// Explanation describes how an object was placed within a single group. This is
+// diagnostic information, e.g. for answering "why did this device land on this endpoint".
+type Explanation struct {
+	// Group is the name of the group.
+	Group string
+
+	// Algorithm is the group's placement algorithm.
+	Algorithm Algorithm
+
+	// Object is the object exactly as it was hashed.
+	Object string
+
+	// Hash is hashy's 64-bit hash of the object, which is the search key of every placement
+	// algorithm. On a ring, it is the search key of the successor ring, while the nearest
+	// endpoint comes from the medley ring, which hashes objects internally.
+	Hash uint64
+
+	// Token describes the position the object matched within the group's placement,
+	// e.g. a ring's member and successor vnode, a jump bucket, or a lookup table slot.
+	Token string
+
+	// Endpoint is the endpoint assigned to the object, which skips draining endpoints.
+	// This is nil if the group has no endpoints available.
+	Endpoint *Endpoint
+
+	// Successor is the endpoint ranked after Endpoint, i.e. the object's next replica.
+	// This is usually, but not always for rings, the endpoint the object would be assigned
+	// to if Endpoint were removed. This is nil if the group has no other endpoint available.
+	Successor *Endpoint
+}
+
+// ExplainString explains how an object is placed in each group, optionally filtered by
+// groups. If no groups are passed, all groups are explained. Missing groups are skipped.
+func (l *Locator) ExplainString(object string, groups ...string) (explanations []Explanation) {
+	defer l.lock.RUnlock()
+	l.lock.RLock()
+
+	if l.groups == nil {
+		return
+	}
+
+	if len(groups) == 0 {
+		for g := range l.groups.All() {
+			groups = append(groups, g.Name())
+		}
+	}
+
+	key := objectKey{str: object, isString: true}
+	explanations = make([]Explanation, 0, len(groups))
+	for _, name := range groups {
+		g, p := l.groups.Get(name), l.placementsByName[name]
+		if g == nil || p == nil {
+			continue
+		}
+
+		e := Explanation{
+			Group:     name,
+			Algorithm: l.algorithmFor(g),
+			Object:    object,
+			Hash:      key.hash(),
+			Token:     p.token(key),
+		}
+
+		assigned := l.appendAssigned(nil, p, key, 2)
+		if len(assigned) > 0 {
+			e.Endpoint = assigned[0].Endpoint
+		}
+
+		if len(assigned) > 1 {
+			e.Successor = assigned[1].Endpoint
+		}
+
+		explanations = append(explanations, e)
+	}
+
+	return
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestExplainRing(t *testing.T) {
+	gps := newSampleGroups(t)
+	loc, err := NewLocator(WithReplicas(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc.Update(gps)
+	for g := range gps.All() {
+		baseline := newBaselineRing(0, g)
+		rp, ok := loc.placementsByName[g.Name()].(*ringPlacement)
+		if !ok {
+			t.Fatalf("expected a ring for group %s", g.Name())
+		}
+
+		for i := range 1000 {
+			object := "mac:" + strconv.Itoa(i)
+			explanations := loc.ExplainString(object, g.Name())
+			if len(explanations) != 1 {
+				t.Fatalf("expected 1 explanation, got %d", len(explanations))
+			}
+
+			e := explanations[0]
+			if e.Algorithm != AlgorithmRing {
+				t.Fatalf("expected the ring algorithm, got %s", e.Algorithm)
+			}
+
+			// the endpoint is the medley ring's nearest, exactly as Find reports it
+			located := loc.FindString(object, g.Name())
+			if e.Endpoint != baseline.NearestString(object) || e.Endpoint != located[0].Endpoint {
+				t.Fatalf("%s was explained on %s, but located on %s", object, e.Endpoint.OriginalName(), located[0].OriginalName())
+			}
+
+			if e.Successor != located[1].Endpoint {
+				t.Fatalf("%s has successor %s, but its next replica is %s", object, e.Successor.OriginalName(), located[1].OriginalName())
+			}
+
+			// the hash is the search key of the successor ring, so it matches the first vnode at or after it
+			v := rp.vnodes[0]
+			for _, candidate := range rp.vnodes {
+				if candidate.token >= e.Hash {
+					v = candidate
+					break
+				}
+			}
+
+			expected := "member " + e.Endpoint.OriginalName()
+			if !strings.HasPrefix(e.Token, expected) || !strings.HasSuffix(e.Token, " vnode "+v.member.id+" "+strconv.FormatUint(v.token, 16)) {
+				t.Fatalf("unexpected token %q", e.Token)
+			}
+		}
+	}
+}
@@ -5,13 +5,15 @@ package service
 
 import (
 	"context"
-	"hash/adler32"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
-	"sort"
-	"sync/atomic"
+	"strconv"
+	"strings"
 	"time"
 
 	"codeberg.org/miekg/dns"
@@ -19,10 +21,16 @@ import (
 	"github.com/xmidt-org/hashy"
 	"github.com/xmidt-org/hashy/config"
 	"github.com/xmidt-org/medley"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 const (
+	// TracerName is the name of the OpenTelemetry tracer for ingesting groups.
+	TracerName = "github.com/xmidt-org/hashy/service"
+
 	// DefaultFileIngesterOrigin is the default $ORIGIN used for parsing zone files.
 	DefaultFileIngesterOrigin = ""
 
@@ -30,6 +38,39 @@ const (
 	DefaultFileIngesterTTL = 5 * time.Minute
 )
 
+// FileErrorPolicy determines what a FileIngester does with a zone file that cannot be ingested.
+type FileErrorPolicy string
+
+const (
+	// FailOnFileError fails the whole ingest, leaving the current groups in place. This is the default.
+	FailOnFileError FileErrorPolicy = "fail"
+
+	// SkipOnFileError skips bad zone files and builds groups from the rest. Each skipped
+	// file is reported in the IngestEvent.
+	SkipOnFileError FileErrorPolicy = "skip"
+)
+
+// errNoZoneFiles indicates that none of a FileIngester's globs matched any files, which
+// usually means the source of zone files is unavailable. When files are required, this is
+// an error rather than an empty set of groups, so that the current groups stay in place.
+var errNoZoneFiles = errors.New("no zone files matched")
+
+// parseErrorLine matches the position at the end of a zone parser's error.
+var parseErrorLine = regexp.MustCompile(` at line: (\d+):\d+$`)
+
+// newFileError describes an error ingesting a zone file. The zone parser only reports
+// positions in the text of its errors, so the line is extracted from that text.
+func newFileError(path string, err error) (fe FileError) {
+	fe.Path = path
+	fe.Message = strings.TrimPrefix(err.Error(), path+": ")
+	if m := parseErrorLine.FindStringSubmatchIndex(fe.Message); m != nil {
+		fe.Line, _ = strconv.Atoi(fe.Message[m[2]:m[3]])
+		fe.Message = fe.Message[:m[0]]
+	}
+
+	return
+}
+
 type FileIngesterOption interface {
 	applyToFileIngester(*FileIngester) error
 }
@@ -49,6 +90,8 @@ func WithIngestLogger(base *zap.Logger) FileIngesterOption {
 	})
 }
 
+// WithGlobs adds patterns for zone files. Patterns support ** for any number of directories,
+// and a pattern that begins with ! excludes the files it matches.
 func WithGlobs(more ...string) FileIngesterOption {
 	return fileIngesterOptionFunc(func(fi *FileIngester) error {
 		fi.globs = slices.Grow(fi.globs, len(more))
@@ -94,6 +137,71 @@ func WithIngestListeners(more ...IngestListener) FileIngesterOption {
 	})
 }
 
+// WithIngestValidators adds validators that the groups from each ingest must pass before they
+// are dispatched. Groups that fail are rejected, and an error event is dispatched instead.
+func WithIngestValidators(more ...IngestValidator) FileIngesterOption {
+	return fileIngesterOptionFunc(func(fi *FileIngester) error {
+		fi.gate.validators = append(fi.gate.validators, more...)
+		return nil
+	})
+}
+
+// WithIngestMetrics sets the prometheus metrics a FileIngester records. By default, no metrics are recorded.
+func WithIngestMetrics(m *IngestMetrics) FileIngesterOption {
+	return fileIngesterOptionFunc(func(fi *FileIngester) error {
+		fi.metrics = m
+		return nil
+	})
+}
+
+// WithIngestTracerProvider sets the OpenTelemetry provider a FileIngester uses to create
+// a span for each ingest, with a child span for each zone file. By default, no spans are recorded.
+func WithIngestTracerProvider(tp trace.TracerProvider) FileIngesterOption {
+	return fileIngesterOptionFunc(func(fi *FileIngester) error {
+		if tp != nil {
+			fi.tracer = tp.Tracer(TracerName)
+		}
+
+		return nil
+	})
+}
+
+// WithOverlay sets the Overlay of dynamic changes merged with the ingested zone files.
+func WithOverlay(o *Overlay) FileIngesterOption {
+	return fileIngesterOptionFunc(func(fi *FileIngester) error {
+		fi.overlay = o
+		return nil
+	})
+}
+
+// WithFileErrorPolicy sets what happens when a zone file cannot be ingested. If unset,
+// FailOnFileError is used.
+func WithFileErrorPolicy(p string) FileIngesterOption {
+	return fileIngesterOptionFunc(func(fi *FileIngester) error {
+		switch FileErrorPolicy(p) {
+		case "":
+			fi.policy = FailOnFileError
+
+		case FailOnFileError, SkipOnFileError:
+			fi.policy = FileErrorPolicy(p)
+
+		default:
+			return fmt.Errorf("unknown file error policy: %s", p)
+		}
+
+		return nil
+	})
+}
+
+// WithRequireFiles sets whether an ingest fails when the globs match no files at all. By
+// default, globs that match nothing produce empty groups.
+func WithRequireFiles(v bool) FileIngesterOption {
+	return fileIngesterOptionFunc(func(fi *FileIngester) error {
+		fi.requireFiles = v
+		return nil
+	})
+}
+
 func WithGroupsConfig(gcfg config.Groups) FileIngesterOption {
 	return fileIngesterOptionFunc(func(fi *FileIngester) (err error) {
 		err = WithDiscoveryDomain(gcfg.DiscoveryDomain).
@@ -114,23 +222,35 @@ func WithGroupsConfig(gcfg config.Groups) FileIngesterOption {
 				applyToFileIngester(fi)
 		}
 
+		if err == nil {
+			err = WithFileErrorPolicy(gcfg.FileErrorPolicy).
+				applyToFileIngester(fi)
+		}
+
+		if err == nil {
+			err = WithRequireFiles(gcfg.RequireFiles).
+				applyToFileIngester(fi)
+		}
+
+		if err == nil {
+			err = WithIngestValidators(NewIngestValidators(gcfg.Validation)...).
+				applyToFileIngester(fi)
+		}
+
 		return
 	})
 }
 
 // FileIngester handles reading in DNS zone files from the filesystem.
 type FileIngester struct {
-	logger          *zap.Logger
-	globs           []string
-	origin          string
-	ttl             uint32
-	discoveryDomain string
-
-	checksummer medley.Constructor[uint32]
-	first       atomic.Bool
-	checksum    atomic.Uint32
-
-	listeners []IngestListener
+	ingestCore
+
+	globs        []string
+	patterns     zoneGlobs
+	origin       string
+	ttl          uint32
+	policy       FileErrorPolicy
+	requireFiles bool
 }
 
 // NewFileIngester creates a FileIngester from a set of options.
@@ -142,51 +262,31 @@ func NewFileIngester(opts ...FileIngesterOption) (*FileIngester, error) {
 		}
 	}
 
-	if fi.logger == nil {
-		fi.logger = zap.NewNop()
+	fi.initialize()
+	var err error
+	if fi.patterns, err = newZoneGlobs(fi.globs...); err != nil {
+		return nil, err
 	}
 
 	if len(fi.origin) == 0 {
 		fi.origin = DefaultFileIngesterOrigin
 	}
 
-	if fi.ttl == 0 {
-		fi.ttl = hashy.DurationToSeconds(DefaultFileIngesterTTL)
-	}
-
-	if len(fi.discoveryDomain) == 0 {
-		// we know this won't cause an error
-		WithDiscoveryDomain(DefaultDiscoveryDomain).
-			applyToFileIngester(fi)
+	if len(fi.policy) == 0 {
+		fi.policy = FailOnFileError
 	}
 
-	if fi.checksummer == nil {
-		fi.checksummer = medley.AsConstructor32(adler32.New)
+	if fi.ttl == 0 {
+		fi.ttl = hashy.DurationToSeconds(DefaultFileIngesterTTL)
 	}
 
 	return fi, nil
 }
 
-// zoneParsers is a sequence of file paths for each configured glob. If any error occurs,
+// zoneFiles is a sequence of file paths for the configured globs. If any error occurs,
 // the yield function is called with a an empty string and the error.
-//
-// Each glob's set of matching files is sorted lexicographically for a consistent
-// processing order.
 func (fi *FileIngester) zoneFiles(yield func(string, error) bool) {
-	for _, glob := range fi.globs {
-		matches, err := filepath.Glob(glob)
-		if err != nil {
-			yield("", err)
-			return
-		}
-
-		sort.Strings(matches)
-		for _, path := range matches {
-			if !yield(path, nil) {
-				return
-			}
-		}
-	}
+	fi.patterns.files(yield)
 }
 
 // newZoneParser creates a *ZoneParser for a path. If the context has expired, or if a problem
@@ -206,94 +306,102 @@ func (fi *FileIngester) newZoneParser(checksummer medley.Hash[uint32], path stri
 	return
 }
 
-func (fi *FileIngester) ingestFile(l *zap.Logger, checksummer medley.Hash[uint32], rrc *RRCollector, path string) error {
+// ingestFile parses a single zone file. The RRs are only returned if all of them are valid,
+// so a bad file never contributes part of its RRs.
+func (fi *FileIngester) ingestFile(ctx context.Context, l *zap.Logger, checksummer medley.Hash[uint32], path string) (rrs []dns.RR, err error) {
+	_, span := fi.tracer.Start(ctx, "ingest.file", trace.WithAttributes(
+		attribute.String("file.path", path),
+	))
+
+	defer func() {
+		span.SetAttributes(attribute.Int("hashy.rrs", len(rrs)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
 	parser, closer, err := fi.newZoneParser(checksummer, path)
 	if err != nil {
-		return err
+		return
 	}
 
 	defer closer.Close()
-	for rr, err := range parser.RRs() {
+	for rr, parseErr := range parser.RRs() {
 		if rr == nil {
 			// a successful end is a nil RR and a nil error
-			// otherwise, err will hold any error that occurred
-			return err
+			// otherwise, parseErr will hold any error that occurred
+			if err = parseErr; err != nil {
+				return
+			}
+
+			break
 		}
 
 		l.Debug("resource record", zap.Stringer("rr", rr))
-		if err := rrc.AddRR(rr); err != nil {
-			return err
-		}
+		rrs = append(rrs, rr)
 	}
 
-	return nil
-}
-
-func (fi *FileIngester) dispatchIngestEvent(event IngestEvent) {
-	for _, l := range fi.listeners {
-		l.OnIngest(event)
+	validator := RRCollector{
+		discoveryDomain: fi.discoveryDomain,
 	}
-}
 
-// Ingest reads in all the files this FileIngester was configured with.
-// This method tracks the checksum across all files. An IngestEvent will
-// only be dispatch if either (a) this is the first Ingest, or (b) if any
-// change in the files occurred.
-func (fi *FileIngester) Ingest(ctx context.Context) {
-	var event IngestEvent
-	rrc := RRCollector{
-		discoveryDomain: fi.discoveryDomain,
+	for _, rr := range rrs {
+		if err = validator.addRR(rr); err != nil {
+			rrs = nil
+			return
+		}
 	}
 
-	oldChecksum := fi.checksum.Load()
-	checksummer := fi.checksummer()
-	fileCount := 0
+	return
+}
 
-	for path, err := range fi.zoneFiles {
-		if err != nil {
-			event.Err = err
-			fi.logger.Error("failed to expand file globs", zap.Error(event.Err))
-			break
+// ReadRRs reads the RRs from every zone file, following this FileIngester's FileErrorPolicy.
+// The contents of every file, including skipped files, are written to the checksummer.
+func (fi *FileIngester) ReadRRs(ctx context.Context, checksummer medley.Hash[uint32]) (src SourceRRs, err error) {
+	for path, globErr := range fi.zoneFiles {
+		if err = globErr; err != nil {
+			fi.logger.Error("failed to expand file globs", zap.Error(err))
+			return
 		}
 
-		event.Err = ctx.Err()
-		if event.Err != nil {
-			break
+		if err = ctx.Err(); err != nil {
+			return
 		}
 
-		fileCount++
+		src.Files++
 		ingestLogger := fi.logger.With(zap.String("path", path))
 		ingestLogger.Debug("parsing zone file")
-		event.Err = fi.ingestFile(ingestLogger, checksummer, &rrc, path)
 
-		if event.Err != nil {
-			fi.logger.Error("error parsing file", zap.String("path", path), zap.Error(err))
-			break
-		}
-	}
+		var rrs []dns.RR
+		if rrs, err = fi.ingestFile(ctx, ingestLogger, checksummer, path); err != nil {
+			src.FileErrors = append(src.FileErrors, newFileError(path, err))
+			if fi.policy != SkipOnFileError {
+				ingestLogger.Error("error parsing file", zap.Error(err))
+				return
+			}
 
-	fi.logger.Info("parsing complete", zap.Int("fileCount", fileCount))
-	if event.Err == nil {
-		newChecksum := checksummer.Value()
-
-		if fi.first.CompareAndSwap(false, true) {
-			// on the first time we ingest, we always build groups and dispatch
-			fi.logger.Info("initial ingest")
-			event.Groups = rrc.Build()
-			fi.checksum.Store(newChecksum)
-		} else if fi.checksum.Load() != newChecksum && fi.checksum.CompareAndSwap(oldChecksum, newChecksum) {
-			// only build groups if there was a change that we recognize
-			fi.logger.Info("changes detected")
-			event.Groups = rrc.Build()
+			ingestLogger.Error("skipping zone file", zap.Error(err))
+			err = nil
 		}
 
-		if event.Groups != nil {
-			fi.dispatchIngestEvent(event)
-		} else {
-			fi.logger.Info("no changes since last ingest")
-		}
-	} else {
-		// always dispatch errors
-		fi.dispatchIngestEvent(event)
+		src.RRs = append(src.RRs, rrs...)
 	}
+
+	if src.Files == 0 && len(fi.globs) > 0 && fi.requireFiles {
+		err = errNoZoneFiles
+		fi.logger.Error("no zone files matched", zap.Strings("globs", fi.globs))
+	}
+
+	return
+}
+
+// Ingest reads in all the files this FileIngester was configured with.
+// This method tracks the checksum across all files. An IngestEvent will
+// only be dispatch if either (a) this is the first Ingest, or (b) if any
+// change in the files occurred.
+func (fi *FileIngester) Ingest(ctx context.Context) {
+	fi.ingest(ctx, fi.ReadRRs)
 }
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttribute returns the value of a span attribute, or an empty value if the span lacks it.
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func TestFileIngesterSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	fi, err := NewFileIngester(
+		WithGlobs("../sample/*.zone"),
+		WithIngestTracerProvider(tp),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi.Ingest(context.Background())
+	spans := exporter.GetSpans()
+
+	var ingest tracetest.SpanStub
+	var files []tracetest.SpanStub
+	for _, span := range spans {
+		switch span.Name {
+		case "ingest":
+			ingest = span
+
+		case "ingest.file":
+			files = append(files, span)
+
+		default:
+			t.Errorf("unexpected span: %s", span.Name)
+		}
+	}
+
+	if ingest.Name != "ingest" {
+		t.Fatal("expected an ingest span")
+	}
+
+	if ingest.Status.Code == codes.Error {
+		t.Errorf("unexpected ingest error: %s", ingest.Status.Description)
+	}
+
+	if v := spanAttribute(ingest, "hashy.files"); v.AsInt64() != 2 {
+		t.Errorf("expected 2 files, got %s", v.Emit())
+	}
+
+	if !spanAttribute(ingest, "hashy.changed").AsBool() {
+		t.Error("expected the first ingest to change the groups")
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("expected a span per file, got %d", len(files))
+	}
+
+	for _, span := range files {
+		if span.Parent.SpanID() != ingest.SpanContext.SpanID() {
+			t.Errorf("expected %s to be a child of the ingest span", spanAttribute(span, "file.path").Emit())
+		}
+
+		if spanAttribute(span, "hashy.rrs").AsInt64() == 0 {
+			t.Errorf("expected %s to record its RRs", spanAttribute(span, "file.path").Emit())
+		}
+	}
+
+	exporter.Reset()
+	fi.Ingest(context.Background())
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "ingest" && spanAttribute(span, "hashy.changed").AsBool() {
+			t.Error("expected an unchanged ingest to record no change")
+		}
+	}
+}
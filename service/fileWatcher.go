@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultWatchDebounce is the default time a FileWatcher waits for a burst of
+	// filesystem changes to end before ingesting.
+	DefaultWatchDebounce = 500 * time.Millisecond
+)
+
+// watchRoot is a directory watched on behalf of a pattern, along with how many levels of
+// subdirectories the pattern can match. A depth of -1 means any depth.
+type watchRoot struct {
+	dir   string
+	depth int
+}
+
+// newWatchRoot determines the directories that must be watched for a pattern, which are the
+// directory before the first meta character and as many levels below it as the pattern can match.
+// A pattern without meta characters is a single file, so its parent directory is watched in order
+// to see the file being replaced.
+func newWatchRoot(pattern string) (wr watchRoot) {
+	wr.dir, wr.depth = globBase(pattern)
+	return
+}
+
+// contains returns true if dir is a directory this root must watch.
+func (wr watchRoot) contains(dir string) bool {
+	rel, err := filepath.Rel(wr.dir, dir)
+	switch {
+	case err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)):
+		return false
+
+	case rel == "." || wr.depth < 0:
+		return true
+
+	default:
+		return len(strings.Split(rel, string(filepath.Separator))) <= wr.depth
+	}
+}
+
+type FileWatcherOption interface {
+	applyToFileWatcher(*FileWatcher) error
+}
+
+type fileWatcherOptionFunc func(*FileWatcher) error
+
+func (f fileWatcherOptionFunc) applyToFileWatcher(fw *FileWatcher) error { return f(fw) }
+
+func WithWatchLogger(base *zap.Logger) FileWatcherOption {
+	return fileWatcherOptionFunc(func(fw *FileWatcher) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		fw.logger = base.Named("fileWatcher")
+		return nil
+	})
+}
+
+// WithWatchGlobs adds the zone file globs to watch, which are typically the same as the FileIngester's.
+func WithWatchGlobs(more ...string) FileWatcherOption {
+	return fileWatcherOptionFunc(func(fw *FileWatcher) error {
+		fw.globs = slices.Grow(fw.globs, len(more))
+		for _, m := range more {
+			fw.globs = append(fw.globs, os.ExpandEnv(m))
+		}
+
+		return nil
+	})
+}
+
+// WithWatchDebounce sets how long to wait for a burst of changes to end. If unset, DefaultWatchDebounce is used.
+func WithWatchDebounce(v time.Duration) FileWatcherOption {
+	return fileWatcherOptionFunc(func(fw *FileWatcher) error {
+		fw.debounce = v
+		return nil
+	})
+}
+
+// WithWatchTrigger sets what is triggered when files change, which is typically the IngestChecker
+// that also ingests on an interval. Ingests then never overlap, and a trigger during an ingest
+// results in one more ingest afterward. This option is required.
+func WithWatchTrigger(t IngestTrigger) FileWatcherOption {
+	return fileWatcherOptionFunc(func(fw *FileWatcher) error {
+		fw.trigger = t
+		return nil
+	})
+}
+
+// FileWatcher watches the directories behind a set of zone file globs and triggers an ingest as
+// soon as any matching file is created, written, renamed, or removed. Bursts of changes, such as an
+// editor's atomic rename, are debounced into a single ingest. Directories created later are
+// watched as well, as long as the globs can match files within them.
+//
+// Only (1) background goroutine will run for any given FileWatcher.
+type FileWatcher struct {
+	logger   *zap.Logger
+	globs    []string
+	patterns zoneGlobs
+	roots    []watchRoot
+	debounce time.Duration
+	trigger  IngestTrigger
+
+	runLock    sync.Mutex
+	cancelFunc context.CancelFunc
+	done       chan struct{}
+}
+
+// NewFileWatcher creates an unstarted FileWatcher using the supplied options.
+func NewFileWatcher(opts ...FileWatcherOption) (*FileWatcher, error) {
+	fw := new(FileWatcher)
+	for _, o := range opts {
+		if err := o.applyToFileWatcher(fw); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case fw.trigger == nil:
+		return nil, errors.New("an IngestTrigger is required for a FileWatcher")
+
+	case len(fw.globs) == 0:
+		return nil, errors.New("at least one glob is required for a FileWatcher")
+	}
+
+	if fw.logger == nil {
+		fw.logger = zap.NewNop()
+	}
+
+	if fw.debounce <= 0 {
+		fw.debounce = DefaultWatchDebounce
+	}
+
+	var err error
+	if fw.patterns, err = newZoneGlobs(fw.globs...); err != nil {
+		return nil, err
+	}
+
+	for _, p := range fw.patterns.includes {
+		fw.roots = append(fw.roots, newWatchRoot(p))
+	}
+
+	return fw, nil
+}
+
+// watchTree adds a directory and each of its subdirectories that some glob can match.
+func (fw *FileWatcher) watchTree(watcher *fsnotify.Watcher, dir string) {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+
+		case !d.IsDir():
+			return nil
+
+		case !fw.watches(path):
+			return filepath.SkipDir
+		}
+
+		fw.logger.Debug("watching directory", zap.String("dir", path))
+		return watcher.Add(path)
+	})
+
+	if err != nil {
+		fw.logger.Warn("unable to watch directory", zap.String("dir", dir), zap.Error(err))
+	}
+}
+
+// watches returns true if dir must be watched for some glob.
+func (fw *FileWatcher) watches(dir string) bool {
+	return slices.ContainsFunc(fw.roots, func(wr watchRoot) bool {
+		return wr.contains(dir)
+	})
+}
+
+// relevant handles a single filesystem event, returning true if an ingest is necessary.
+// A new directory is watched, and is relevant because it may already hold matching files.
+func (fw *FileWatcher) relevant(watcher *fsnotify.Watcher, event fsnotify.Event) bool {
+	if event.Has(fsnotify.Create) && fw.watches(event.Name) {
+		if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+			fw.watchTree(watcher, event.Name)
+			return true
+		}
+	}
+
+	return !event.Has(fsnotify.Chmod) && fw.patterns.matches(event.Name)
+}
+
+// run is a goroutine that triggers an ingest after each burst of relevant events, until the context is canceled.
+func (fw *FileWatcher) run(ctx context.Context, watcher *fsnotify.Watcher, done chan<- struct{}) {
+	defer close(done)
+	defer watcher.Close()
+
+	timer := time.NewTimer(fw.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if fw.relevant(watcher, event) {
+				fw.logger.Debug("zone file event", zap.Stringer("event", event))
+				timer.Reset(fw.debounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			fw.logger.Warn("watch error", zap.Error(err))
+
+		case <-timer.C:
+			fw.logger.Info("zone files changed")
+			fw.trigger.Trigger()
+		}
+	}
+}
+
+// Start atomically starts watching. This method is idempotent.
+func (fw *FileWatcher) Start() error {
+	defer fw.runLock.Unlock()
+	fw.runLock.Lock()
+
+	if fw.cancelFunc != nil {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	for _, wr := range fw.roots {
+		fw.watchTree(watcher, wr.dir)
+	}
+
+	var ctx context.Context
+	ctx, fw.cancelFunc = context.WithCancel(context.Background())
+	fw.done = make(chan struct{})
+	go fw.run(ctx, watcher, fw.done)
+	return nil
+}
+
+// Stop atomically halts watching, waiting for any ingest in progress. This method is idempotent.
+func (fw *FileWatcher) Stop() {
+	defer fw.runLock.Unlock()
+	fw.runLock.Lock()
+
+	if fw.cancelFunc != nil {
+		fw.cancelFunc()
+		<-fw.done
+		fw.cancelFunc = nil
+		fw.done = nil
+	}
+}
@@ -14,9 +14,11 @@ import (
 
 // Group is a single group of servers.
 type Group struct {
-	name      string
-	services  []string
-	endpoints []Endpoint
+	name       string
+	services   []string
+	attributes Attributes
+	endpoints  []Endpoint
+	unresolved []string
 }
 
 func (g *Group) Len() int {
@@ -31,6 +33,12 @@ func (g *Group) Services() iter.Seq[string] {
 	return slices.Values(g.services)
 }
 
+// Attributes returns the attributes from this group's definition. The returned
+// Attributes must not be modified.
+func (g *Group) Attributes() Attributes {
+	return g.attributes
+}
+
 func (g *Group) Endpoints() iter.Seq[*Endpoint] {
 	return func(yield func(*Endpoint) bool) {
 		for i := range len(g.endpoints) {
@@ -41,6 +49,12 @@ func (g *Group) Endpoints() iter.Seq[*Endpoint] {
 	}
 }
 
+// Unresolved returns the SRV targets of this group's services that have no addresses.
+// These targets are not endpoints of this group.
+func (g *Group) Unresolved() iter.Seq[string] {
+	return slices.Values(g.unresolved)
+}
+
 // LenRRs returns the number of RRs this group will produce of the given type.
 func (g *Group) LenRRs(rrType uint16) (n int) {
 	if rrType == dns.TypeTXT {
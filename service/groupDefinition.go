@@ -66,19 +66,42 @@ func validateGroupName(name string) error {
 // GroupDefinition holds the discovered information about a group. Typically, group definitions
 // will come from TXT records.
 type GroupDefinition struct {
-	Name     string
-	Services []string
+	Name       string
+	Services   []string
+	Attributes Attributes
+}
+
+// validateGroupAttribute checks the values of the group attributes that hashy understands.
+func validateGroupAttribute(key, value string) (err error) {
+	if key == AlgorithmAttribute {
+		_, err = ParseAlgorithm(value)
+	}
+
+	return
 }
 
 // ParseGroupDefinition parses the textual representation of a group's discovered information.
 // The text must be a valid US-ASCII string with 2 or more fields delimited by whitespace.
 // The first field is the group's name, and all subsequent fields are the services (SRV records)
-// that hold the members of the group.
+// that hold the members of the group. Subsequent fields of the form key=value are attributes
+// of the group rather than services, e.g. "algorithm=rendezvous".
 func ParseGroupDefinition(txt string) (gdef GroupDefinition, err error) {
 	if fields := strings.Fields(txt); len(fields) > 1 {
 		gdef.Name = fields[0]
-		gdef.Services = fields[1:]
 		err = validateGroupName(gdef.Name)
+		for _, field := range fields[1:] {
+			if err != nil {
+				break
+			}
+
+			if key, value, found := strings.Cut(field, "="); found {
+				if err = validateGroupAttribute(key, value); err == nil {
+					gdef.Attributes.set(key, value)
+				}
+			} else {
+				gdef.Services = append(gdef.Services, field)
+			}
+		}
 	} else {
 		err = errInvalidGroupDefinition
 	}
@@ -92,7 +115,8 @@ func ParseGroupDefinition(txt string) (gdef GroupDefinition, err error) {
 
 // MergeGroupDefinitions merges each of a sequence of definitions into a single definition.
 // The returned definition has the name of the first definition passed to this function.
-// The services are merged into a single slice, but are not deduped or sorted.
+// The services are merged into a single slice, but are not deduped or sorted. Attributes
+// are merged such that later definitions take precedence.
 //
 // If no definitions are passed to this function, it returns an empty definition.
 func MergeGroupDefinitions(defs ...GroupDefinition) (merged GroupDefinition) {
@@ -105,6 +129,7 @@ func MergeGroupDefinitions(defs ...GroupDefinition) (merged GroupDefinition) {
 		for _, d := range defs {
 			merged.Services = slices.Grow(merged.Services, len(d.Services))
 			merged.Services = append(merged.Services, d.Services...)
+			merged.Attributes.merge(d.Attributes)
 		}
 	}
 
@@ -0,0 +1,465 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/hashy"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultHealthInterval is the default time between rounds of health probes.
+	DefaultHealthInterval = 10 * time.Second
+
+	// DefaultHealthTimeout is the default time allowed for a single health probe.
+	DefaultHealthTimeout = 2 * time.Second
+
+	// DefaultUnhealthyThreshold is the default number of consecutive failed probes
+	// before a healthy endpoint is considered unhealthy.
+	DefaultUnhealthyThreshold = 3
+
+	// DefaultHealthyThreshold is the default number of consecutive successful probes
+	// before an unhealthy endpoint is readmitted.
+	DefaultHealthyThreshold = 2
+)
+
+// HealthTarget identifies what a HealthChecker probes: an SRV target, which is an
+// endpoint's original name together with its port. The same name with different
+// ports is probed, and can be unhealthy, separately.
+type HealthTarget struct {
+	Name string
+	Port uint16
+}
+
+// healthTargetOf returns the HealthTarget of an endpoint.
+func healthTargetOf(e *Endpoint) HealthTarget {
+	return HealthTarget{
+		Name: e.OriginalName(),
+		Port: e.Port(),
+	}
+}
+
+// String returns the target in name:port form.
+func (ht HealthTarget) String() string {
+	return net.JoinHostPort(ht.Name, strconv.Itoa(int(ht.Port)))
+}
+
+// HealthEvent holds information about a change in the health of endpoints.
+type HealthEvent struct {
+	// Unhealthy holds the targets of all endpoints that are currently unhealthy.
+	// This set must not be modified.
+	Unhealthy hashy.Deduper[HealthTarget]
+}
+
+// HealthListener is a sink for HealthEvents.
+type HealthListener interface {
+	// OnHealthChange notifies this listener that the set of unhealthy endpoints has changed.
+	OnHealthChange(HealthEvent)
+}
+
+// HealthProbe checks whether a single endpoint is healthy.
+type HealthProbe interface {
+	// Probe returns an error if the endpoint is not healthy. The context
+	// carries the timeout for this probe.
+	Probe(context.Context, *Endpoint) error
+}
+
+// probeAddrs invokes a function for each address of an endpoint until one succeeds.
+func probeAddrs(e *Endpoint, f func(netip.AddrPort) error) (err error) {
+	err = fmt.Errorf("endpoint [%s] has no addresses", e.OriginalName())
+	for addr := range e.Addrs() {
+		if err = f(netip.AddrPortFrom(addr, e.Port())); err == nil {
+			return
+		}
+	}
+
+	return
+}
+
+// TCPProbe checks that a TCP connection can be made to an endpoint's SRV port.
+// An endpoint is healthy if any of its addresses accepts a connection.
+type TCPProbe struct {
+	// Dialer is the optional dialer to use. If unset, a default net.Dialer is used.
+	Dialer *net.Dialer
+}
+
+func (tp TCPProbe) Probe(ctx context.Context, e *Endpoint) error {
+	d := tp.Dialer
+	if d == nil {
+		d = new(net.Dialer)
+	}
+
+	return probeAddrs(e, func(ap netip.AddrPort) error {
+		conn, err := d.DialContext(ctx, "tcp", ap.String())
+		if err == nil {
+			conn.Close()
+		}
+
+		return err
+	})
+}
+
+// HTTPProbe checks that an HTTP GET against an endpoint's SRV port returns a 2xx status.
+// An endpoint is healthy if any of its addresses responds successfully.
+type HTTPProbe struct {
+	// Client is the optional HTTP client to use. If unset, http.DefaultClient is used.
+	Client *http.Client
+
+	// Scheme is the URL scheme. If unset, http is used.
+	Scheme string
+
+	// Path is the URL path to GET, e.g. "/health". If unset, "/" is used.
+	Path string
+}
+
+func (hp HTTPProbe) Probe(ctx context.Context, e *Endpoint) error {
+	client := hp.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	scheme := hp.Scheme
+	if len(scheme) == 0 {
+		scheme = "http"
+	}
+
+	path := hp.Path
+	if len(path) == 0 || path[0] != '/' {
+		path = "/" + path
+	}
+
+	return probeAddrs(e, func(ap netip.AddrPort) error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+ap.String()+path, nil)
+		if err != nil {
+			return err
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+
+		response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return errors.New("unexpected status: " + strconv.Itoa(response.StatusCode))
+		}
+
+		return nil
+	})
+}
+
+type HealthCheckerOption interface {
+	applyToHealthChecker(*HealthChecker) error
+}
+
+type healthCheckerOptionFunc func(*HealthChecker) error
+
+func (f healthCheckerOptionFunc) applyToHealthChecker(hc *HealthChecker) error { return f(hc) }
+
+func WithHealthLogger(base *zap.Logger) HealthCheckerOption {
+	return healthCheckerOptionFunc(func(hc *HealthChecker) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		hc.logger = base.Named("health")
+		return nil
+	})
+}
+
+// WithHealthProbe sets the probe used to check endpoints. This option is required.
+func WithHealthProbe(p HealthProbe) HealthCheckerOption {
+	return healthCheckerOptionFunc(func(hc *HealthChecker) error {
+		hc.probe = p
+		return nil
+	})
+}
+
+// WithHealthInterval sets the time between rounds of probes. If unset,
+// DefaultHealthInterval is used.
+func WithHealthInterval(v time.Duration) HealthCheckerOption {
+	return healthCheckerOptionFunc(func(hc *HealthChecker) error {
+		hc.interval = v
+		return nil
+	})
+}
+
+// WithHealthTimeout sets the time allowed for each probe. If unset,
+// DefaultHealthTimeout is used.
+func WithHealthTimeout(v time.Duration) HealthCheckerOption {
+	return healthCheckerOptionFunc(func(hc *HealthChecker) error {
+		hc.timeout = v
+		return nil
+	})
+}
+
+// WithHealthThresholds sets the hysteresis for endpoint health. A healthy endpoint becomes
+// unhealthy after the given number of consecutive failed probes, and an unhealthy endpoint
+// is readmitted only after the given number of consecutive successful probes. Nonpositive
+// values use DefaultUnhealthyThreshold and DefaultHealthyThreshold.
+func WithHealthThresholds(unhealthy, healthy int) HealthCheckerOption {
+	return healthCheckerOptionFunc(func(hc *HealthChecker) error {
+		hc.unhealthyThreshold = unhealthy
+		hc.healthyThreshold = healthy
+		return nil
+	})
+}
+
+func WithHealthListeners(more ...HealthListener) HealthCheckerOption {
+	return healthCheckerOptionFunc(func(hc *HealthChecker) error {
+		hc.listeners = append(hc.listeners, more...)
+		return nil
+	})
+}
+
+// endpointHealth is the probe history of a single endpoint.
+type endpointHealth struct {
+	endpoint  *Endpoint
+	unhealthy bool
+
+	// streak is the number of consecutive probes that disagreed with the current state
+	streak int
+}
+
+// HealthChecker periodically probes each endpoint of the current groups. Endpoints that
+// fail enough consecutive probes are reported as unhealthy to HealthListeners, such as a
+// Locator, which leave those endpoints off their groups' rings.
+//
+// A HealthChecker is an IngestListener, which is how it learns the endpoints to probe.
+// New endpoints start out healthy. Only (1) background goroutine will run for any given HealthChecker.
+type HealthChecker struct {
+	logger             *zap.Logger
+	probe              HealthProbe
+	interval           time.Duration
+	timeout            time.Duration
+	unhealthyThreshold int
+	healthyThreshold   int
+	listeners          []HealthListener
+
+	stateLock sync.Mutex
+	endpoints map[HealthTarget]*endpointHealth
+	unhealthy hashy.Deduper[HealthTarget]
+
+	runLock    sync.Mutex
+	cancelFunc context.CancelFunc
+}
+
+// NewHealthChecker creates an unstarted HealthChecker using the supplied options.
+// If no HealthProbe was supplied, this function returns an error.
+func NewHealthChecker(opts ...HealthCheckerOption) (*HealthChecker, error) {
+	hc := &HealthChecker{
+		endpoints: make(map[HealthTarget]*endpointHealth),
+	}
+
+	for _, o := range opts {
+		if err := o.applyToHealthChecker(hc); err != nil {
+			return nil, err
+		}
+	}
+
+	if hc.probe == nil {
+		return nil, errors.New("a HealthProbe is required for a HealthChecker")
+	}
+
+	if hc.logger == nil {
+		hc.logger = zap.NewNop()
+	}
+
+	if hc.interval <= 0 {
+		hc.interval = DefaultHealthInterval
+	}
+
+	if hc.timeout <= 0 {
+		hc.timeout = DefaultHealthTimeout
+	}
+
+	if hc.unhealthyThreshold < 1 {
+		hc.unhealthyThreshold = DefaultUnhealthyThreshold
+	}
+
+	if hc.healthyThreshold < 1 {
+		hc.healthyThreshold = DefaultHealthyThreshold
+	}
+
+	return hc, nil
+}
+
+// OnIngest updates the set of endpoints being probed. The probe history of endpoints
+// that are still present is retained.
+func (hc *HealthChecker) OnIngest(event IngestEvent) {
+	if event.Err != nil {
+		return
+	}
+
+	hc.stateLock.Lock()
+	endpoints := make(map[HealthTarget]*endpointHealth, len(hc.endpoints))
+	for g := range event.Groups.All() {
+		for e := range g.Endpoints() {
+			target := healthTargetOf(e)
+			if _, exists := endpoints[target]; exists {
+				continue
+			}
+
+			eh := hc.endpoints[target]
+			if eh == nil {
+				eh = new(endpointHealth)
+			}
+
+			eh.endpoint = e
+			endpoints[target] = eh
+		}
+	}
+
+	hc.endpoints = endpoints
+	healthEvent, changed := hc.updateUnhealthy()
+	hc.stateLock.Unlock()
+
+	if changed {
+		hc.dispatch(healthEvent)
+	}
+}
+
+// updateUnhealthy recomputes the set of unhealthy endpoints. If that set changed, this
+// method returns the HealthEvent to dispatch. The state lock must be held.
+func (hc *HealthChecker) updateUnhealthy() (event HealthEvent, changed bool) {
+	unhealthy := make(hashy.Deduper[HealthTarget])
+	for target, eh := range hc.endpoints {
+		if eh.unhealthy {
+			unhealthy.Add(target)
+		}
+	}
+
+	if !maps.Equal(unhealthy, hc.unhealthy) {
+		hc.unhealthy = unhealthy
+		event, changed = HealthEvent{Unhealthy: unhealthy}, true
+	}
+
+	return
+}
+
+// dispatch sends a HealthEvent to each listener.
+func (hc *HealthChecker) dispatch(event HealthEvent) {
+	hc.logger.Info("endpoint health changed", zap.Int("unhealthy", len(event.Unhealthy)))
+	for _, l := range hc.listeners {
+		l.OnHealthChange(event)
+	}
+}
+
+// Check runs a single round of probes against every known endpoint, concurrently,
+// and dispatches a HealthEvent if the set of unhealthy endpoints changed.
+func (hc *HealthChecker) Check(ctx context.Context) {
+	hc.stateLock.Lock()
+	endpoints := make([]*endpointHealth, 0, len(hc.endpoints))
+	for _, eh := range hc.endpoints {
+		endpoints = append(endpoints, eh)
+	}
+
+	hc.stateLock.Unlock()
+
+	results := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, eh := range endpoints {
+		wg.Go(func() {
+			probeCtx, cancel := context.WithTimeout(ctx, hc.timeout)
+			results[i] = hc.probe.Probe(probeCtx, eh.endpoint)
+			cancel()
+		})
+	}
+
+	wg.Wait()
+	if ctx.Err() != nil {
+		// probes that were canceled say nothing about endpoint health
+		return
+	}
+
+	hc.stateLock.Lock()
+	for i, eh := range endpoints {
+		hc.record(eh, results[i])
+	}
+
+	event, changed := hc.updateUnhealthy()
+	hc.stateLock.Unlock()
+
+	if changed {
+		hc.dispatch(event)
+	}
+}
+
+// record applies the result of a single probe to an endpoint's history. The state lock must be held.
+func (hc *HealthChecker) record(eh *endpointHealth, err error) {
+	if (err != nil) == eh.unhealthy {
+		// the probe agrees with the current state
+		eh.streak = 0
+		return
+	}
+
+	eh.streak++
+	threshold := hc.unhealthyThreshold
+	if eh.unhealthy {
+		threshold = hc.healthyThreshold
+	}
+
+	if eh.streak >= threshold {
+		eh.unhealthy = !eh.unhealthy
+		eh.streak = 0
+
+		hc.logger.Info(
+			"endpoint health changed",
+			zap.Stringer("target", healthTargetOf(eh.endpoint)),
+			zap.Bool("healthy", !eh.unhealthy),
+			zap.Error(err),
+		)
+	}
+}
+
+// run is a goroutine that invokes Check on an interval until the context is canceled.
+func (hc *HealthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			hc.Check(ctx)
+		}
+	}
+}
+
+// Start atomically starts probing endpoints on the configured interval.
+// This method is idempotent.
+func (hc *HealthChecker) Start() {
+	defer hc.runLock.Unlock()
+	hc.runLock.Lock()
+
+	if hc.cancelFunc == nil {
+		var ctx context.Context
+		ctx, hc.cancelFunc = context.WithCancel(context.Background())
+		go hc.run(ctx)
+	}
+}
+
+// Stop atomically halts probing endpoints. This method is idempotent.
+func (hc *HealthChecker) Stop() {
+	defer hc.runLock.Unlock()
+	hc.runLock.Lock()
+
+	if hc.cancelFunc != nil {
+		hc.cancelFunc()
+		hc.cancelFunc = nil
+	}
+}
@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy/config"
+)
+
+// newTestGroups builds the Groups for static group declarations, as an ingest would.
+func newTestGroups(t *testing.T, static ...config.StaticGroup) *Groups {
+	t.Helper()
+	doc, err := NewStaticDocument(static)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rrs, err := doc.RRs(DefaultDiscoveryDomain, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rrc := RRCollector{
+		discoveryDomain: dnsutil.Fqdn(DefaultDiscoveryDomain),
+	}
+
+	for _, rr := range rrs {
+		if err := rrc.AddRR(rr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return rrc.Build()
+}
+
+// newTestEndpoint creates a loopback endpoint for a server address.
+func newTestEndpoint(t *testing.T, address string) *Endpoint {
+	t.Helper()
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Endpoint{
+		originalName: "test.example.org.",
+		port:         ap.Port(),
+		ip4:          []netip.Addr{ap.Addr()},
+	}
+}
+
+// listen starts a TCP listener on loopback that accepts and closes connections.
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Close()
+		}
+	}()
+
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// healthEvents is a HealthListener that records each event.
+type healthEvents struct {
+	lock   sync.Mutex
+	events []HealthEvent
+}
+
+func (he *healthEvents) OnHealthChange(event HealthEvent) {
+	he.lock.Lock()
+	he.events = append(he.events, event)
+	he.lock.Unlock()
+}
+
+func (he *healthEvents) len() int {
+	he.lock.Lock()
+	defer he.lock.Unlock()
+	return len(he.events)
+}
+
+func (he *healthEvents) last() HealthEvent {
+	he.lock.Lock()
+	defer he.lock.Unlock()
+	return he.events[len(he.events)-1]
+}
+
+func TestTCPProbe(t *testing.T) {
+	l := listen(t)
+	e := newTestEndpoint(t, l.Addr().String())
+	if err := (TCPProbe{}).Probe(context.Background(), e); err != nil {
+		t.Fatalf("expected a listening endpoint to be healthy: %s", err)
+	}
+
+	l.Close()
+	if err := (TCPProbe{}).Probe(context.Background(), e); err == nil {
+		t.Fatal("expected a closed endpoint to be unhealthy")
+	}
+
+	if err := (TCPProbe{}).Probe(context.Background(), new(Endpoint)); err == nil {
+		t.Fatal("expected an endpoint without addresses to be unhealthy")
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(int(status.Load()))
+	}))
+
+	t.Cleanup(server.Close)
+	e := newTestEndpoint(t, server.Listener.Addr().String())
+
+	testCases := []struct {
+		path    string
+		status  int
+		healthy bool
+	}{
+		{path: "/health", status: http.StatusOK, healthy: true},
+		{path: "health", status: http.StatusNoContent, healthy: true},
+		{path: "/health", status: http.StatusServiceUnavailable, healthy: false},
+		{path: "", status: http.StatusOK, healthy: false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("path=%q,status=%d", testCase.path, testCase.status), func(t *testing.T) {
+			status.Store(int32(testCase.status))
+			err := HTTPProbe{Path: testCase.path}.Probe(context.Background(), e)
+			if healthy := err == nil; healthy != testCase.healthy {
+				t.Errorf("expected healthy=%t, got error: %v", testCase.healthy, err)
+			}
+		})
+	}
+}
+
+// newHealthGroups creates a single group with one endpoint per listener, all on loopback.
+func newHealthGroups(t *testing.T, listeners ...net.Listener) *Groups {
+	t.Helper()
+	var endpoints []config.StaticEndpoint
+	for i, l := range listeners {
+		ap := netip.MustParseAddrPort(l.Addr().String())
+		endpoints = append(endpoints, config.StaticEndpoint{
+			Name:      "e" + strconv.Itoa(i) + ".example.org.",
+			Addresses: []string{ap.Addr().String()},
+			Port:      ap.Port(),
+			Weight:    1,
+		})
+	}
+
+	return newTestGroups(t, config.StaticGroup{
+		Name: "test",
+		Services: []config.StaticService{
+			{Name: "_test._tcp.example.org.", Endpoints: endpoints},
+		},
+	})
+}
+
+func TestHealthCheckerHysteresis(t *testing.T) {
+	var (
+		events   healthEvents
+		failing  = listen(t)
+		listener = listen(t)
+		gps      = newHealthGroups(t, failing, listener)
+	)
+
+	hc, err := NewHealthChecker(
+		WithHealthProbe(TCPProbe{}),
+		WithHealthThresholds(2, 3),
+		WithHealthListeners(&events),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hc.OnIngest(IngestEvent{Groups: gps})
+	hc.Check(context.Background())
+	if events.len() != 0 {
+		t.Fatal("expected no events while every endpoint is healthy")
+	}
+
+	// restart the failing endpoint's listener later on the same port
+	address := failing.Addr().String()
+	failing.Close()
+	hc.Check(context.Background())
+	if events.len() != 0 {
+		t.Fatal("expected a single failed probe to be tolerated")
+	}
+
+	hc.Check(context.Background())
+	if events.len() != 1 {
+		t.Fatalf("expected an unhealthy event, got %d events", events.len())
+	}
+
+	expected := HealthTarget{Name: "e0.example.org.", Port: netip.MustParseAddrPort(address).Port()}
+	if unhealthy := events.last().Unhealthy; unhealthy.Len() != 1 {
+		t.Fatalf("expected exactly one unhealthy target, got %v", unhealthy)
+	} else if _, ok := unhealthy[expected]; !ok {
+		t.Fatalf("expected %s to be unhealthy, got %v", expected, unhealthy)
+	}
+
+	restarted, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("unable to restart listener on %s: %s", address, err)
+	}
+
+	t.Cleanup(func() { restarted.Close() })
+	for range 2 {
+		hc.Check(context.Background())
+		if events.len() != 1 {
+			t.Fatal("expected an unhealthy endpoint to stay out until the healthy threshold")
+		}
+	}
+
+	hc.Check(context.Background())
+	if events.len() != 2 || events.last().Unhealthy.Len() != 0 {
+		t.Fatal("expected the endpoint to be readmitted")
+	}
+}
+
+func TestHealthCheckerTargets(t *testing.T) {
+	gps := newTestGroups(t,
+		config.StaticGroup{
+			Name: "first",
+			Services: []config.StaticService{
+				{
+					Name: "_first._tcp.example.org.",
+					Endpoints: []config.StaticEndpoint{
+						{Name: "shared.example.org.", Addresses: []string{"127.0.0.1"}, Port: 8080, Weight: 1},
+					},
+				},
+			},
+		},
+		config.StaticGroup{
+			Name: "second",
+			Services: []config.StaticService{
+				{
+					Name: "_second._tcp.example.org.",
+					Endpoints: []config.StaticEndpoint{
+						{Name: "shared.example.org.", Addresses: []string{"127.0.0.1"}, Port: 9090, Weight: 1},
+					},
+				},
+			},
+		},
+	)
+
+	var probed sync.Map
+	hc, err := NewHealthChecker(
+		WithHealthProbe(healthProbeFunc(func(_ context.Context, e *Endpoint) error {
+			probed.Store(healthTargetOf(e), true)
+			return nil
+		})),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hc.OnIngest(IngestEvent{Groups: gps})
+	hc.Check(context.Background())
+	for _, port := range []uint16{8080, 9090} {
+		if _, ok := probed.Load(HealthTarget{Name: "shared.example.org.", Port: port}); !ok {
+			t.Errorf("expected port %d to be probed separately", port)
+		}
+	}
+}
+
+// healthProbeFunc adapts a closure to a HealthProbe.
+type healthProbeFunc func(context.Context, *Endpoint) error
+
+func (f healthProbeFunc) Probe(ctx context.Context, e *Endpoint) error { return f(ctx, e) }
+
+func TestHealthExclusionMovesOnlyFailingKeys(t *testing.T) {
+	for _, algorithm := range []Algorithm{AlgorithmRing, AlgorithmRendezvous, AlgorithmJump, AlgorithmMaglev} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			listeners := []net.Listener{listen(t), listen(t), listen(t), listen(t)}
+			gps := newHealthGroups(t, listeners...)
+
+			loc, err := NewLocator(WithAlgorithm(string(algorithm)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hc, err := NewHealthChecker(
+				WithHealthProbe(TCPProbe{}),
+				WithHealthThresholds(1, 1),
+				WithHealthListeners(loc),
+			)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loc.Update(gps)
+			hc.OnIngest(IngestEvent{Groups: gps})
+
+			const objects = 2000
+			before := make([]*Endpoint, objects)
+			for i := range objects {
+				before[i] = loc.FindString("device-"+strconv.Itoa(i), "test")[0].Endpoint
+			}
+
+			listeners[0].Close()
+			hc.Check(context.Background())
+
+			failing := "e0.example.org."
+			moved := 0
+			for i := range objects {
+				after := loc.FindString("device-"+strconv.Itoa(i), "test")[0].Endpoint
+				switch {
+				case after.OriginalName() == failing:
+					t.Fatalf("object %d is still placed on the unhealthy endpoint", i)
+
+				case before[i].OriginalName() == failing:
+					moved++
+
+				case after.OriginalName() != before[i].OriginalName():
+					t.Fatalf("object %d moved from the healthy endpoint %s to %s", i, before[i].OriginalName(), after.OriginalName())
+				}
+			}
+
+			if moved == 0 {
+				t.Fatal("expected objects on the unhealthy endpoint to move")
+			}
+		})
+	}
+}
@@ -6,16 +6,52 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"hash/adler32"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/medley"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
 )
 
 const (
 	// DefaultCheckInterval is the default time that a FileIngester rechecks external sources
 	// for DNS RRs that affect how hashy operates.
 	DefaultCheckInterval = 10 * time.Minute
+
+	// MinCheckInterval is the least time an IngestChecker waits between ingests, regardless
+	// of when an IngestScheduler asks for the next one.
+	MinCheckInterval = time.Second
 )
 
+// FileError describes a zone file that could not be ingested.
+type FileError struct {
+	// Path is the path of the zone file.
+	Path string
+
+	// Line is the line of the zone file where the error occurred, or 0 if the line is not known.
+	Line int
+
+	// Message describes the error.
+	Message string
+}
+
+func (fe FileError) Error() string {
+	if fe.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", fe.Path, fe.Line, fe.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", fe.Path, fe.Message)
+}
+
 // IngestEvent holds information about an update to the set of groups.
 type IngestEvent struct {
 	// Err contains any error that occurred. If this is non-nil, Groups
@@ -24,6 +60,51 @@ type IngestEvent struct {
 
 	// Lists holds the ingested groups.
 	Groups *Groups
+
+	// FileErrors holds the zone files that could not be ingested. When bad files are
+	// skipped, Err is nil and Groups were built from the remaining files.
+	FileErrors []FileError
+}
+
+// IngestStatus describes the outcome of the most recent ingest.
+type IngestStatus struct {
+	// Checksum is the checksum of the data that produced the current groups.
+	Checksum uint32
+
+	// Time is when the most recent ingest completed.
+	Time time.Time
+
+	// Err is the error from the most recent ingest, if any.
+	Err error
+
+	// FileErrors holds the zone files the most recent ingest could not ingest.
+	FileErrors []FileError
+
+	// Sources holds the health of each source, for ingesters that merge several sources.
+	Sources []SourceStatus
+}
+
+// SourceRRs holds the RRs read from an RRSource.
+type SourceRRs struct {
+	// RRs are the records read from the source.
+	RRs []dns.RR
+
+	// Files is the number of files read, for sources that read files.
+	Files int
+
+	// FileErrors holds the files that could not be read.
+	FileErrors []FileError
+
+	// Sources holds the health of each source, for sources that merge several others.
+	Sources []SourceStatus
+}
+
+// RRSource is a source of the DNS RRs that groups are built from. Each of hashy's
+// ingesters is an RRSource, which allows a CompositeIngester to merge several sources.
+type RRSource interface {
+	// ReadRRs reads the current RRs from this source. Anything that identifies the state
+	// of this source, such as file contents or zone serials, is written to the checksummer.
+	ReadRRs(ctx context.Context, checksummer medley.Hash[uint32]) (SourceRRs, error)
 }
 
 // IngestListener is a sink for IngestEvents.
@@ -42,6 +123,204 @@ type Ingester interface {
 	Ingest(context.Context)
 }
 
+// StatusIngester is an Ingester that reports the outcome of its most recent ingest.
+type StatusIngester interface {
+	Ingester
+
+	// Status returns the outcome of the most recent ingest.
+	Status() IngestStatus
+
+	// SeedAccepted sets the groups that the first ingest's groups are validated against,
+	// such as the groups restored from a snapshot. It has no effect after an ingest is accepted.
+	SeedAccepted(*Groups)
+}
+
+// IngestTrigger requests an ingest outside of the usual interval, without waiting for it.
+// An IngestChecker implements this interface.
+type IngestTrigger interface {
+	// Trigger requests an ingest as soon as possible. Several triggers may result in a single ingest.
+	Trigger()
+}
+
+// ZoneIngester is implemented by Ingesters that pull zones from primary servers, such as a
+// TransferIngester, so that a NOTIFY for one of those zones reaches the ingester that owns it.
+type ZoneIngester interface {
+	// HasZone tests if this ingester pulls a zone.
+	HasZone(zone string) bool
+}
+
+// ZoneTrigger requests an ingest of a single zone outside of the usual interval, e.g. when
+// its primary sends a NOTIFY. An IngestChecker implements this interface.
+type ZoneTrigger interface {
+	// TriggerZone requests an ingest from the ingester that owns a zone, just like Trigger.
+	// It returns false, and triggers nothing, if no ingester owns the zone.
+	TriggerZone(zone string) bool
+}
+
+// IngestScheduler is implemented by Ingesters whose sources expire, such as resolved DNS records.
+// An IngestChecker ingests at the time an IngestScheduler asks for, if that is sooner than its interval.
+type IngestScheduler interface {
+	// NextIngest returns when the next ingest is due. The zero time means no ingest is due before the interval.
+	NextIngest() time.Time
+}
+
+// ingestCore is the state that every ingester shares: where its groups go, how they are
+// validated, and the outcome of its most recent ingest. Each ingester embeds an ingestCore
+// and supplies only the function that reads its records.
+type ingestCore struct {
+	logger          *zap.Logger
+	discoveryDomain string
+	overlay         *Overlay
+	gate            ingestGate
+	listeners       []IngestListener
+	metrics         *IngestMetrics
+	tracer          trace.Tracer
+	checksummer     medley.Constructor[uint32]
+
+	// ingestLock serializes ingests, which update the state below along with any
+	// state of the ingester itself, such as the serials of transferred zones
+	ingestLock sync.Mutex
+	first      bool
+	checksum   uint32
+	status     atomic.Pointer[IngestStatus]
+}
+
+// initialize sets the defaults of anything an ingester's options left unset.
+func (core *ingestCore) initialize() {
+	if core.logger == nil {
+		core.logger = zap.NewNop()
+	}
+
+	if len(core.discoveryDomain) == 0 {
+		core.discoveryDomain = dnsutil.Fqdn(DefaultDiscoveryDomain)
+	}
+
+	if core.tracer == nil {
+		core.tracer = noop.NewTracerProvider().Tracer(TracerName)
+	}
+
+	if core.checksummer == nil {
+		core.checksummer = medley.AsConstructor32(adler32.New)
+	}
+}
+
+// Status returns the outcome of the most recent Ingest. Before the first Ingest
+// completes, this method returns the zero value.
+func (core *ingestCore) Status() (status IngestStatus) {
+	if p := core.status.Load(); p != nil {
+		status = *p
+	}
+
+	return
+}
+
+// SeedAccepted sets the groups that the first ingest's groups are validated against.
+func (core *ingestCore) SeedAccepted(gps *Groups) {
+	core.gate.seed(gps)
+}
+
+// ingest reads records with the given function and builds groups from them, along with
+// the overlay. An IngestEvent is only dispatched if either (a) there was an error, (b) this is
+// the first successful ingest, or (c) the checksum of the records or the overlay changed.
+func (core *ingestCore) ingest(ctx context.Context, read func(context.Context, medley.Hash[uint32]) (SourceRRs, error)) {
+	defer core.ingestLock.Unlock()
+	core.ingestLock.Lock()
+
+	ctx, span := core.tracer.Start(ctx, "ingest")
+	defer span.End()
+
+	var overlayVersion uint64
+	if core.overlay != nil {
+		overlayVersion = core.overlay.version()
+	}
+
+	start := time.Now()
+	checksummer := core.checksummer()
+	src, err := read(ctx, checksummer)
+	event := IngestEvent{
+		Err:        err,
+		FileErrors: src.FileErrors,
+	}
+
+	rrc := RRCollector{
+		discoveryDomain: core.discoveryDomain,
+		overlay:         core.overlay,
+	}
+
+	rrCounts := make(map[uint16]int)
+	for i := 0; event.Err == nil && i < len(src.RRs); i++ {
+		rrCounts[dns.RRToType(src.RRs[i])]++
+		event.Err = rrc.AddRR(src.RRs[i])
+	}
+
+	if event.Err == nil && core.overlay != nil {
+		if len(src.FileErrors) == 0 {
+			// skipped files may hold records that deletions hide, so only a complete read scopes them
+			event.Err = core.overlay.scope(overlayVersion, rrc.hidden)
+		}
+
+		// changes to the overlay must change the checksum, just like changes to records
+		if event.Err == nil {
+			event.Err = core.overlay.checksum(checksummer)
+		}
+
+		if event.Err == nil {
+			event.Err = rrc.addOverlay()
+		}
+	}
+
+	core.logger.Info("reading complete", zap.Int("fileCount", src.Files), zap.Int("fileErrors", len(src.FileErrors)), zap.Int("rrs", len(src.RRs)))
+	core.metrics.observe(start, src.Files, rrCounts, event.Err)
+	span.SetAttributes(attribute.Int("hashy.files", src.Files))
+
+	newChecksum := checksummer.Value()
+	switch {
+	case event.Err != nil:
+		// errors are always dispatched
+
+	case !core.first:
+		core.logger.Info("initial ingest")
+		event.Groups = rrc.Build()
+
+	case core.checksum != newChecksum:
+		core.logger.Info("changes detected")
+		event.Groups = rrc.Build()
+	}
+
+	if event.Groups != nil {
+		if event.Err = core.gate.admit(event.Groups); event.Err == nil {
+			core.first = true
+			core.checksum = newChecksum
+		} else {
+			// the checksum is left alone, so the next ingest validates these records again
+			core.logger.Error("groups rejected", zap.Error(event.Err))
+			core.metrics.reject()
+			event.Groups = nil
+		}
+	}
+
+	core.status.Store(&IngestStatus{
+		Checksum:   core.checksum,
+		Time:       time.Now(),
+		Err:        event.Err,
+		FileErrors: event.FileErrors,
+		Sources:    src.Sources,
+	})
+
+	span.SetAttributes(attribute.Bool("hashy.changed", event.Groups != nil))
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	} else if event.Groups == nil {
+		core.logger.Info("no changes since last ingest")
+		return
+	}
+
+	for _, l := range core.listeners {
+		l.OnIngest(event)
+	}
+}
+
 type IngestCheckerOption interface {
 	applyToIngestChecker(*IngestChecker) error
 }
@@ -65,12 +344,13 @@ func WithIngester(i Ingester) IngestCheckerOption {
 }
 
 // IngestChecker manages a single goroutine that invokes Ingest on a particular Ingester
-// on an interval.
+// on an interval, or sooner when triggered.
 //
 // Only (1) background goroutine will run for any given IngestChecker.
 type IngestChecker struct {
 	interval time.Duration
 	ingester Ingester
+	triggers chan struct{}
 
 	runLock    sync.Mutex
 	cancelFunc context.CancelFunc
@@ -79,7 +359,10 @@ type IngestChecker struct {
 // NewIngestChecker creates an unstarted IngestChecker using the supplied options.
 // If no Ingester was supplied in the options, this method returns an error.
 func NewIngestChecker(opts ...IngestCheckerOption) (*IngestChecker, error) {
-	ic := new(IngestChecker)
+	ic := &IngestChecker{
+		triggers: make(chan struct{}, 1),
+	}
+
 	for _, o := range opts {
 		if err := o.applyToIngestChecker(ic); err != nil {
 			return nil, err
@@ -97,26 +380,65 @@ func NewIngestChecker(opts ...IngestCheckerOption) (*IngestChecker, error) {
 	return ic, nil
 }
 
+// wait returns how long to wait before the next ingest.
+func (ic *IngestChecker) wait() time.Duration {
+	if s, ok := ic.ingester.(IngestScheduler); ok {
+		if next := s.NextIngest(); !next.IsZero() {
+			return max(min(time.Until(next), ic.interval), MinCheckInterval)
+		}
+	}
+
+	return ic.interval
+}
+
 // run is a goroutine that simply invokes Ingest on an interval until
-// the context is canceled.
+// the context is canceled. If the Ingester is an IngestScheduler, ingests
+// happen sooner when it asks for them.
 //
 // Since an IngestChecker is immutable, this method does not need to
 // contend on a lock to use state.
 func (ic *IngestChecker) run(ctx context.Context) {
-	ticker := time.NewTicker(ic.interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(ic.wait())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
-		case <-ticker.C:
+		case <-timer.C:
 			ic.ingester.Ingest(ctx)
+			timer.Reset(ic.wait())
+
+		case <-ic.triggers:
+			ic.ingester.Ingest(ctx)
+			timer.Reset(ic.wait())
 		}
 	}
 }
 
+// Trigger requests an ingest as soon as possible, rather than waiting for the interval.
+// Triggers coalesce, so any number of triggers while an ingest is pending result in a
+// single ingest. A trigger before Start is held until this IngestChecker starts.
+func (ic *IngestChecker) Trigger() {
+	select {
+	case ic.triggers <- struct{}{}:
+	default:
+		// an ingest is already pending
+	}
+}
+
+// TriggerZone requests an ingest if this IngestChecker's Ingester is a ZoneIngester that
+// owns a zone. Otherwise, this method returns false and nothing is triggered.
+func (ic *IngestChecker) TriggerZone(zone string) bool {
+	if zi, ok := ic.ingester.(ZoneIngester); ok && zi.HasZone(zone) {
+		ic.Trigger()
+		return true
+	}
+
+	return false
+}
+
 // Start atomically starts invoking Ingest on the configured interval.
 // This method is idempotent.
 func (ic *IngestChecker) Start() {
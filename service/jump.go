@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+)
+
+// maxJumpAttempts is the number of times a key is jumped again before a jumpPlacement
+// falls back to scanning for the next usable bucket.
+const maxJumpAttempts = 32
+
+// jumpPlacement is jump consistent hashing, as described by Lamping and Veach. Every member
+// of the group is a bucket, ordered by endpoint name, even if its endpoint is unavailable or
+// outside the best priority tier. A key that lands on such a bucket is jumped again with a
+// derived key, so losing an endpoint only moves that endpoint's keys.
+//
+// Successors follow the same sequence of derived keys, skipping the endpoints already chosen,
+// so an object's successor is exactly where it moves if its endpoint becomes unavailable.
+type jumpPlacement struct {
+	members   []*member
+	placed    map[*Endpoint]bool
+	endpoints int
+}
+
+func newJumpPlacement(members []*member, placed map[*Endpoint]bool) *jumpPlacement {
+	return &jumpPlacement{
+		members:   members,
+		placed:    placed,
+		endpoints: len(placed),
+	}
+}
+
+// jump returns the bucket in the range [0, buckets) for a key.
+func jump(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+
+	return int(b)
+}
+
+// Len returns the number of endpoints objects can be placed onto.
+func (jp *jumpPlacement) Len() int {
+	return jp.endpoints
+}
+
+// usable tests if an object may be placed onto a bucket, given the endpoints already chosen.
+func (jp *jumpPlacement) usable(bucket int, chosen LocatedEndpoints) bool {
+	e := jp.members[bucket].endpoint
+	return jp.placed[e] && !chosen.contains(e)
+}
+
+// bucket returns the first usable bucket for an object hash. There must be at least
+// one usable bucket.
+func (jp *jumpPlacement) bucket(objectHash uint64, chosen LocatedEndpoints) int {
+	h := objectHash
+	b := jump(h, len(jp.members))
+	for attempt := uint64(1); attempt <= maxJumpAttempts; attempt++ {
+		if jp.usable(b, chosen) {
+			return b
+		}
+
+		h = mix64(objectHash + attempt)
+		b = jump(h, len(jp.members))
+	}
+
+	// very few buckets are usable, so scanning keeps lookups bounded
+	for !jp.usable(b, chosen) {
+		if b++; b == len(jp.members) {
+			b = 0
+		}
+	}
+
+	return b
+}
+
+func (jp *jumpPlacement) appendNearest(dst LocatedEndpoints, n int, key objectKey) LocatedEndpoints {
+	if jp.Len() == 0 || n < 1 {
+		return dst
+	}
+
+	start := len(dst)
+	objectHash := key.hash()
+	for rank := 0; rank < n && rank < jp.Len(); rank++ {
+		dst = append(dst, LocatedEndpoint{
+			Endpoint: jp.members[jp.bucket(objectHash, dst[start:])].endpoint,
+			Rank:     rank,
+		})
+	}
+
+	return dst
+}
+
+// token returns the bucket the object landed on.
+func (jp *jumpPlacement) token(key objectKey) string {
+	if jp.Len() == 0 {
+		return ""
+	}
+
+	bucket := jp.bucket(key.hash(), nil)
+	return fmt.Sprintf("bucket %d/%d %s", bucket, len(jp.members), jp.members[bucket].id)
+}
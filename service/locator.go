@@ -5,18 +5,46 @@ package service
 
 import (
 	"iter"
+	"slices"
 	"sync"
 
 	"codeberg.org/miekg/dns"
 	"codeberg.org/miekg/dns/rdata"
-	"github.com/xmidt-org/medley"
+	"github.com/xmidt-org/hashy"
 	"github.com/xmidt-org/medley/consistent"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// DefaultReplicas is the default number of endpoints a Locator returns for each group.
+	DefaultReplicas = 1
+)
+
+// LocatedEndpoint is a single endpoint discovered by a Locator.
+type LocatedEndpoint struct {
+	*Endpoint
+
+	// Rank is the position of this endpoint within its group's results. The nearest
+	// endpoint has a rank of 0, its successor on the ring has a rank of 1, and so on.
+	Rank int
+}
+
 // LocatedEndpoints is a collection of endpoints that were discovered by a Locator.
-type LocatedEndpoints []*Endpoint
+// The endpoints are ordered by rank, so the nearest endpoints for each group come first,
+// followed by their successors.
+type LocatedEndpoints []LocatedEndpoint
+
+// contains tests if this set has a given endpoint.
+func (le LocatedEndpoints) contains(e *Endpoint) bool {
+	for _, located := range le {
+		if located.Endpoint == e {
+			return true
+		}
+	}
+
+	return false
+}
 
 // LenRRs returns the total count of all RRs of a given type in this set.
 // This will be the number of tuples returned by the RRs() sequence.
@@ -36,13 +64,13 @@ func (le LocatedEndpoints) LenRRs(rrType uint16) (n int) {
 	return
 }
 
-// RRs returns a sequence of (*Endpoint, dns.RR) tuples. Each RR is of the
+// RRs returns a sequence of (LocatedEndpoint, dns.RR) tuples. Each RR is of the
 // given type. Each RR's Class will be set to ClassINET, but will otherwise
 // be uninitialized.
-func (le LocatedEndpoints) RRs(rrType uint16) iter.Seq2[*Endpoint, dns.RR] {
+func (le LocatedEndpoints) RRs(rrType uint16) iter.Seq2[LocatedEndpoint, dns.RR] {
 	switch rrType {
 	case dns.TypeA:
-		return func(yield func(*Endpoint, dns.RR) bool) {
+		return func(yield func(LocatedEndpoint, dns.RR) bool) {
 			for _, endpoint := range le {
 				for _, addr := range endpoint.ip4 {
 					rr := &dns.A{
@@ -62,7 +90,7 @@ func (le LocatedEndpoints) RRs(rrType uint16) iter.Seq2[*Endpoint, dns.RR] {
 		}
 
 	case dns.TypeAAAA:
-		return func(yield func(*Endpoint, dns.RR) bool) {
+		return func(yield func(LocatedEndpoint, dns.RR) bool) {
 			for _, endpoint := range le {
 				for _, addr := range endpoint.ip6 {
 					rr := &dns.AAAA{
@@ -82,7 +110,7 @@ func (le LocatedEndpoints) RRs(rrType uint16) iter.Seq2[*Endpoint, dns.RR] {
 		}
 
 	default:
-		return emptyRRs[*Endpoint]
+		return emptyRRs[LocatedEndpoint]
 	}
 }
 
@@ -108,19 +136,90 @@ func WithLocatorLogger(base *zap.Logger) LocatorOption {
 func WithVNodes(vnodes int) LocatorOption {
 	return locatorOptionFunc(func(l *Locator) error {
 		l.builder.VNodes(vnodes)
+		l.vnodes = vnodes
 		return nil
 	})
 }
 
-// Locator is a service locator backed by one or more medley consistent hash Rings.
-type Locator struct {
-	logger  *zap.Logger
-	builder consistent.Builder[string, *Endpoint]
+// WithLocatorMetrics sets the prometheus metrics a Locator records. By default, no metrics are recorded.
+func WithLocatorMetrics(m *LocatorMetrics) LocatorOption {
+	return locatorOptionFunc(func(l *Locator) error {
+		l.metrics = m
+		return nil
+	})
+}
+
+// WithReplicas sets the number of distinct endpoints returned for each group.
+// The first endpoint is always the nearest endpoint on the group's ring, and the
+// rest are its successors in ring order. If unset, DefaultReplicas is used.
+func WithReplicas(replicas int) LocatorOption {
+	return locatorOptionFunc(func(l *Locator) error {
+		l.replicas = replicas
+		return nil
+	})
+}
+
+// WithAvailability adds checks for whether endpoints may be placed on their group's ring.
+// An endpoint must be available according to every check. By default, all endpoints are
+// available.
+func WithAvailability(more ...Availability) LocatorOption {
+	return locatorOptionFunc(func(l *Locator) error {
+		l.availability = slices.Grow(l.availability, len(more))
+		l.availability = append(l.availability, more...)
+		return nil
+	})
+}
+
+// WithAlgorithm sets the default placement algorithm for groups. A group can override this
+// with an AlgorithmAttribute in its definition. If unset, DefaultAlgorithm is used.
+func WithAlgorithm(name string) LocatorOption {
+	return locatorOptionFunc(func(l *Locator) (err error) {
+		l.algorithm, err = ParseAlgorithm(name)
+		return
+	})
+}
+
+// WithLoadFactor enables bounded loads. No endpoint receives more than this factor times
+// its share of its group's capacity, e.g. 1.25. A factor of zero, which is the default,
+// disables bounded loads. Any other factor must be greater than one.
+//
+// An endpoint's capacity is its CapacityAttribute, if present, or its weight.
+func WithLoadFactor(factor float64) LocatorOption {
+	return locatorOptionFunc(func(l *Locator) error {
+		l.loadFactor = factor
+		return validateLoadFactor(factor)
+	})
+}
+
+// Readiness reports whether hashy is ready to answer requests. A Locator is
+// ready once it has a non-empty set of groups.
+type Readiness interface {
+	// Ready tests whether requests can be answered.
+	Ready() bool
+}
 
-	lock        sync.RWMutex
-	groups      *Groups
-	ringsByName map[string]*consistent.Ring[*Endpoint]
-	allRings    []*consistent.Ring[*Endpoint]
+// Locator is a service locator that places objects onto the endpoints of each group.
+// Each group uses its own placement algorithm, which by default is a consistent hash ring.
+type Locator struct {
+	logger       *zap.Logger
+	builder      consistent.Builder[string, *member]
+	vnodes       int
+	metrics      *LocatorMetrics
+	replicas     int
+	availability availabilities
+	algorithm    Algorithm
+	loadFactor   float64
+
+	// updateLock serializes rebuilding placements, which happens both
+	// on ingest and whenever endpoint health changes
+	updateLock sync.Mutex
+	unhealthy  hashy.Deduper[HealthTarget]
+
+	lock             sync.RWMutex
+	drained          map[string]bool
+	groups           *Groups
+	placementsByName map[string]placement
+	allPlacements    []placement
 }
 
 func NewLocator(opts ...LocatorOption) (*Locator, error) {
@@ -135,27 +234,43 @@ func NewLocator(opts ...LocatorOption) (*Locator, error) {
 		loc.logger = zap.NewNop()
 	}
 
+	if loc.replicas < 1 {
+		loc.replicas = DefaultReplicas
+	}
+
+	if len(loc.algorithm) == 0 {
+		loc.algorithm = DefaultAlgorithm
+	}
+
+	if loc.vnodes < 1 {
+		loc.vnodes = DefaultVNodes
+	}
+
+	if loc.metrics != nil {
+		loc.metrics.VNodes.Set(float64(loc.vnodes))
+	}
+
 	return loc, nil
 }
 
-// rings produces a sequence of rings, optionally filtered by group.
-// If no groups are passed, all rings are returned. If any groups are missing,
-// no ring is pushed for that group name.
+// placements produces a sequence of placements, optionally filtered by group.
+// If no groups are passed, all placements are returned. If any groups are missing,
+// no placement is pushed for that group name.
 //
 // No concurrency protection is provided by this method.  Callers must contend on the lock.
-func (l *Locator) rings(groups []string) iter.Seq[*consistent.Ring[*Endpoint]] {
-	return func(yield func(*consistent.Ring[*Endpoint]) bool) {
+func (l *Locator) placements(groups []string) iter.Seq[placement] {
+	return func(yield func(placement) bool) {
 		if len(groups) > 0 {
 			for _, groupName := range groups {
-				if ring := l.ringsByName[groupName]; ring != nil {
-					if !yield(ring) {
+				if p := l.placementsByName[groupName]; p != nil {
+					if !yield(p) {
 						return
 					}
 				}
 			}
 		} else {
-			for _, ring := range l.allRings {
-				if !yield(ring) {
+			for _, p := range l.allPlacements {
+				if !yield(p) {
 					return
 				}
 			}
@@ -163,28 +278,177 @@ func (l *Locator) rings(groups []string) iter.Seq[*consistent.Ring[*Endpoint]] {
 	}
 }
 
-func (l *Locator) Find(object []byte, groups ...string) (results LocatedEndpoints) {
+// find locates the configured number of replicas for each group, optionally filtered by group.
+// If assign is true, draining endpoints are replaced by their successors.
+func (l *Locator) find(groups []string, key objectKey, assign bool) (results LocatedEndpoints) {
 	defer l.lock.RUnlock()
 	l.lock.RLock()
 
-	results = make(LocatedEndpoints, 0, len(l.allRings)) // worst case
-	for ring := range l.rings(groups) {
-		results = append(results, ring.Nearest(object))
+	results = make(LocatedEndpoints, 0, len(l.allPlacements)*l.replicas) // worst case
+	for p := range l.placements(groups) {
+		if assign {
+			results = l.appendAssigned(results, p, key, l.replicas)
+		} else {
+			results = p.appendNearest(results, l.replicas, key)
+		}
+	}
+
+	if l.replicas > 1 {
+		// order by rank, retaining the group order within each rank
+		slices.SortStableFunc(results, func(le1, le2 LocatedEndpoint) int {
+			return le1.Rank - le2.Rank
+		})
 	}
 
 	return
 }
 
-func (l *Locator) FindString(object string, groups ...string) (results LocatedEndpoints) {
+// appendAssigned appends the given number of replicas from a placement, skipping
+// draining endpoints. If every endpoint in the placement is draining, the draining
+// endpoints are used anyway so that the group still has an answer.
+//
+// No concurrency protection is provided by this method.  Callers must contend on the lock.
+func (l *Locator) appendAssigned(dst LocatedEndpoints, p placement, key objectKey, replicas int) LocatedEndpoints {
+	var (
+		start    = len(dst)
+		n        = replicas
+		draining int
+	)
+
+	for {
+		dst = p.appendNearest(dst[:start], n, key)
+		draining = 0
+		for _, le := range dst[start:] {
+			if l.draining(le.Endpoint) {
+				draining++
+			}
+		}
+
+		if draining == 0 {
+			return dst
+		} else if len(dst)-start-draining >= replicas || n >= p.Len() {
+			break
+		}
+
+		// ask for enough successors to replace the draining endpoints
+		n = max(n+1, replicas+draining)
+	}
+
+	if draining == len(dst)-start {
+		// everything is draining
+		return p.appendNearest(dst[:start], replicas, key)
+	}
+
+	assigned := slices.DeleteFunc(dst[start:], func(le LocatedEndpoint) bool {
+		return l.draining(le.Endpoint)
+	})
+
+	assigned = assigned[:min(len(assigned), replicas)]
+	for rank := range assigned {
+		assigned[rank].Rank = rank
+	}
+
+	return dst[:start+len(assigned)]
+}
+
+// Find locates the endpoints for an object, optionally filtered by groups. If no groups
+// are passed, all groups are searched. Draining endpoints are included, so Find reports
+// where an object hashes to.
+func (l *Locator) Find(object []byte, groups ...string) LocatedEndpoints {
+	return l.find(groups, objectKey{bytes: object}, false)
+}
+
+// FindString is like Find, but uses a string object.
+func (l *Locator) FindString(object string, groups ...string) LocatedEndpoints {
+	return l.find(groups, objectKey{str: object, isString: true}, false)
+}
+
+// Assign locates the endpoints that new connections for an object should use. This is like
+// Find, except that draining endpoints are replaced by their successors.
+func (l *Locator) Assign(object []byte, groups ...string) LocatedEndpoints {
+	return l.find(groups, objectKey{bytes: object}, true)
+}
+
+// AssignString is like Assign, but uses a string object.
+func (l *Locator) AssignString(object string, groups ...string) LocatedEndpoints {
+	return l.find(groups, objectKey{str: object, isString: true}, true)
+}
+
+// draining tests if an endpoint is in maintenance, either through its DrainAttribute
+// or through Drain.
+//
+// No concurrency protection is provided by this method.  Callers must contend on the lock.
+func (l *Locator) draining(e *Endpoint) bool {
+	return l.drained[e.OriginalName()] || drainingAttribute(e)
+}
+
+// Drain puts an endpoint, by original name, into or out of maintenance. This is in addition
+// to the endpoint's DrainAttribute, and is retained across ingests for as long as some group
+// has the endpoint. If no current group has the endpoint, nothing changes and this method
+// returns false.
+func (l *Locator) Drain(originalName string, drain bool) bool {
+	defer l.lock.Unlock()
+	l.lock.Lock()
+
+	if !l.hasEndpoint(originalName) {
+		return false
+	}
+
+	if drain {
+		if l.drained == nil {
+			l.drained = make(map[string]bool)
+		}
+
+		l.drained[originalName] = true
+	} else {
+		delete(l.drained, originalName)
+	}
+
+	return true
+}
+
+// hasEndpoint tests if any current group has an endpoint, by original name.
+//
+// No concurrency protection is provided by this method.  Callers must contend on the lock.
+func (l *Locator) hasEndpoint(originalName string) bool {
+	if l.groups != nil {
+		for g := range l.groups.All() {
+			for e := range g.Endpoints() {
+				if e.OriginalName() == originalName {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// pruneDrained forgets drained endpoints that no current group has, so that the
+// drained endpoints never outgrow the groups.
+//
+// No concurrency protection is provided by this method.  Callers must contend on the lock.
+func (l *Locator) pruneDrained() {
+	for name := range l.drained {
+		if !l.hasEndpoint(name) {
+			l.logger.Info("endpoint removed while draining", zap.String("originalName", name))
+			delete(l.drained, name)
+		}
+	}
+}
+
+// Draining tests whether an endpoint is currently in maintenance.
+func (l *Locator) Draining(e *Endpoint) bool {
 	defer l.lock.RUnlock()
 	l.lock.RLock()
 
-	results = make(LocatedEndpoints, 0, len(l.allRings)) // worst case
-	for ring := range l.rings(groups) {
-		results = append(results, ring.NearestString(object))
-	}
+	return l.draining(e)
+}
 
-	return
+// Ready reports whether this Locator has any groups to place objects onto.
+func (l *Locator) Ready() bool {
+	gps := l.Groups()
+	return gps != nil && gps.Len() > 0
 }
 
 func (l *Locator) Groups() (gps *Groups) {
@@ -205,44 +469,122 @@ func (l *Locator) OnIngest(event IngestEvent) {
 	l.Update(event.Groups)
 }
 
+// OnHealthChange rebuilds the current placements, leaving unhealthy endpoints off
+// their groups' rings. Only the keyspace of endpoints whose health changed moves.
+func (l *Locator) OnHealthChange(event HealthEvent) {
+	defer l.updateLock.Unlock()
+	l.updateLock.Lock()
+
+	l.unhealthy = event.Unhealthy
+	if gps := l.Groups(); gps != nil {
+		l.rebuild(gps)
+	}
+}
+
+// available tests whether an endpoint may be placed. The update lock must be held.
+func (l *Locator) available(e *Endpoint) bool {
+	if _, unhealthy := l.unhealthy[healthTargetOf(e)]; unhealthy {
+		return false
+	}
+
+	return l.availability.Available(e)
+}
+
+// algorithmFor returns the placement algorithm for a group.
+func (l *Locator) algorithmFor(g *Group) Algorithm {
+	if v, exists := g.Attributes().Get(AlgorithmAttribute); exists {
+		// group definitions are validated as they are parsed
+		a, _ := ParseAlgorithm(v)
+		return a
+	}
+
+	return l.algorithm
+}
+
+// newPlacement creates the placement for a group using that group's algorithm.
+// If bounded loads are enabled, the placement is wrapped to enforce them.
+// The update lock must be held.
+func (l *Locator) newPlacement(g *Group) (p placement) {
+	a := AvailabilityFunc(l.available)
+	switch l.algorithmFor(g) {
+	case AlgorithmRendezvous:
+		p = newRendezvousPlacement(placedMembers(g, a))
+
+	case AlgorithmJump:
+		// jump buckets are positions, so every member of the group keeps its bucket
+		p = newJumpPlacement(groupMembers(g, a))
+
+	case AlgorithmMaglev:
+		p = newMaglevPlacement(placedMembers(g, a))
+
+	default:
+		p = newRingPlacement(l.builder, l.vnodes, placedMembers(g, a))
+	}
+
+	if l.loadFactor > 0.0 {
+		p = newBoundedPlacement(p, l.loadFactor)
+	}
+
+	return
+}
+
 func (l *Locator) Update(gps *Groups) {
 	if l.logger.Level().Enabled(zapcore.DebugLevel) {
 		for g := range gps.All() {
 			l.logger.Debug("group",
 				zap.String("name", g.Name()),
 				zap.Strings("services", g.services),
+				zap.String("algorithm", string(l.algorithmFor(g))),
 			)
 
 			for e := range g.Endpoints() {
 				l.logger.Debug("endpoint",
 					zap.String("group", g.Name()),
 					zap.String("originalName", e.OriginalName()),
+					zap.Uint16("priority", e.Priority()),
+					zap.Uint16("weight", e.Weight()),
 				)
 			}
 		}
 	}
 
-	ringsByName := make(map[string]*consistent.Ring[*Endpoint], gps.Len())
-	rings := make([]*consistent.Ring[*Endpoint], 0, gps.Len())
+	defer l.updateLock.Unlock()
+	l.updateLock.Lock()
+	l.rebuild(gps)
+}
 
-	for group := range gps.All() {
-		ring := l.builder.Build(
-			group.Len(),
-			medley.Objectify(
-				func(s *Endpoint) string {
-					return s.OriginalName()
-				},
-				group.Endpoints(),
-			),
-		)
+// rebuild creates the placements for a set of groups and atomically makes them
+// current. The update lock must be held.
+func (l *Locator) rebuild(gps *Groups) {
+	placementsByName := make(map[string]placement, gps.Len())
+	placements := make([]placement, 0, gps.Len())
 
-		ringsByName[group.Name()] = ring
-		rings = append(rings, ring)
+	for group := range gps.All() {
+		p := l.newPlacement(group)
+		placementsByName[group.Name()] = p
+		placements = append(placements, p)
 	}
 
 	l.lock.Lock()
 	l.groups = gps
-	l.ringsByName = ringsByName
-	l.allRings = rings
+	l.placementsByName = placementsByName
+	l.allPlacements = placements
+	l.pruneDrained()
 	l.lock.Unlock()
+
+	l.observe(placementsByName)
+}
+
+// observe records the current placements. The update lock must be held.
+func (l *Locator) observe(placementsByName map[string]placement) {
+	if l.metrics == nil {
+		return
+	}
+
+	l.metrics.Updates.Inc()
+	l.metrics.Groups.Set(float64(len(placementsByName)))
+	l.metrics.Endpoints.Reset()
+	for name, p := range placementsByName {
+		l.metrics.Endpoints.WithLabelValues(name).Set(float64(p.Len()))
+	}
 }
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import "fmt"
+
+// maglevTableSizes are the prime lookup table sizes used by Maglev hashing. The smallest
+// size that is at least maglevSlotsPerMember times the number of members is used.
+var maglevTableSizes = []uint64{65537, 131071, 262139, 524287, 1048573}
+
+const maglevSlotsPerMember = 100
+
+// maglevPlacement is Maglev hashing, as described by Eisenbud et al. Each member fills
+// slots of a prime sized lookup table according to its own permutation of the slots.
+// Successors are the next distinct endpoints found by walking the table from an object's slot.
+type maglevPlacement struct {
+	members   []*member
+	endpoints int
+	table     []int32
+}
+
+func newMaglevPlacement(members []*member) *maglevPlacement {
+	mp := &maglevPlacement{
+		members:   members,
+		endpoints: distinctEndpoints(members),
+	}
+
+	if len(members) > 0 {
+		mp.populate()
+	}
+
+	return mp
+}
+
+// populate fills this placement's lookup table.
+func (mp *maglevPlacement) populate() {
+	size := maglevTableSizes[len(maglevTableSizes)-1]
+	for _, candidate := range maglevTableSizes {
+		if candidate >= uint64(len(mp.members))*maglevSlotsPerMember {
+			size = candidate
+			break
+		}
+	}
+
+	var (
+		offsets = make([]uint64, len(mp.members))
+		skips   = make([]uint64, len(mp.members))
+		next    = make([]uint64, len(mp.members))
+	)
+
+	for i, m := range mp.members {
+		offsets[i] = m.hash % size
+		skips[i] = mix64(^m.hash)%(size-1) + 1
+	}
+
+	mp.table = make([]int32, size)
+	for i := range mp.table {
+		mp.table[i] = -1
+	}
+
+	for filled := uint64(0); filled < size; {
+		for i := range mp.members {
+			slot := (offsets[i] + next[i]*skips[i]) % size
+			for mp.table[slot] >= 0 {
+				next[i]++
+				slot = (offsets[i] + next[i]*skips[i]) % size
+			}
+
+			mp.table[slot] = int32(i)
+			next[i]++
+			filled++
+			if filled == size {
+				break
+			}
+		}
+	}
+}
+
+// Len returns the number of endpoints objects can be placed onto.
+func (mp *maglevPlacement) Len() int {
+	return mp.endpoints
+}
+
+func (mp *maglevPlacement) appendNearest(dst LocatedEndpoints, n int, key objectKey) LocatedEndpoints {
+	if mp.Len() == 0 || n < 1 {
+		return dst
+	}
+
+	var (
+		start = len(dst)
+		size  = uint64(len(mp.table))
+		slot  = key.hash() % size
+	)
+
+	for i := uint64(0); i < size && len(dst)-start < min(n, mp.Len()); i++ {
+		e := mp.members[mp.table[(slot+i)%size]].endpoint
+		if !dst[start:].contains(e) {
+			dst = append(dst, LocatedEndpoint{
+				Endpoint: e,
+				Rank:     len(dst) - start,
+			})
+		}
+	}
+
+	return dst
+}
+
+// token returns the lookup table slot the object hashed to.
+func (mp *maglevPlacement) token(key objectKey) string {
+	if mp.Len() == 0 {
+		return ""
+	}
+
+	slot := key.hash() % uint64(len(mp.table))
+	return fmt.Sprintf("slot %d/%d %s", slot, len(mp.table), mp.members[mp.table[slot]].id)
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// registerAll registers several collectors, stopping at the first error.
+func registerAll(r prometheus.Registerer, cs ...prometheus.Collector) (err error) {
+	for _, c := range cs {
+		if err = r.Register(c); err != nil {
+			break
+		}
+	}
+
+	return
+}
+
+// IngestMetrics holds the prometheus collectors for ingesting groups.
+type IngestMetrics struct {
+	// Duration is the time taken by each ingest.
+	Duration prometheus.Histogram
+
+	// Files is the number of files parsed by the most recent ingest.
+	Files prometheus.Gauge
+
+	// RRs is the number of RRs, by type, read by the most recent ingest.
+	RRs *prometheus.GaugeVec
+
+	// Errors counts failed ingests.
+	Errors prometheus.Counter
+
+	// LastSuccess is the unix time of the most recent successful ingest.
+	LastSuccess prometheus.Gauge
+
+	// Rejections counts ingests whose groups failed validation.
+	Rejections prometheus.Counter
+}
+
+// NewIngestMetrics creates the ingest metrics and registers them with the given Registerer.
+func NewIngestMetrics(r prometheus.Registerer) (m *IngestMetrics, err error) {
+	m = &IngestMetrics{
+		Duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "hashy",
+			Subsystem: "ingest",
+			Name:      "duration_seconds",
+			Help:      "The time taken to ingest groups.",
+		}),
+		Files: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "hashy",
+			Subsystem: "ingest",
+			Name:      "files",
+			Help:      "The number of files parsed by the most recent ingest.",
+		}),
+		RRs: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "hashy",
+				Subsystem: "ingest",
+				Name:      "rrs",
+				Help:      "The number of RRs, by type, read by the most recent ingest.",
+			},
+			[]string{"type"},
+		),
+		Errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "hashy",
+			Subsystem: "ingest",
+			Name:      "errors_total",
+			Help:      "The number of failed ingests.",
+		}),
+		LastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "hashy",
+			Subsystem: "ingest",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "The unix time of the most recent successful ingest.",
+		}),
+		Rejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "hashy",
+			Subsystem: "ingest",
+			Name:      "rejections_total",
+			Help:      "The number of ingests whose groups failed validation.",
+		}),
+	}
+
+	if err = registerAll(r, m.Duration, m.Files, m.RRs, m.Errors, m.LastSuccess, m.Rejections); err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// observe records the outcome of a single ingest. A nil IngestMetrics records nothing.
+func (m *IngestMetrics) observe(start time.Time, files int, rrCounts map[uint16]int, err error) {
+	if m == nil {
+		return
+	}
+
+	m.Duration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.Errors.Inc()
+		return
+	}
+
+	m.Files.Set(float64(files))
+	m.RRs.Reset()
+	for rrType, count := range rrCounts {
+		label, exists := dns.TypeToString[rrType]
+		if !exists {
+			label = strconv.Itoa(int(rrType))
+		}
+
+		m.RRs.WithLabelValues(label).Set(float64(count))
+	}
+
+	m.LastSuccess.Set(float64(time.Now().Unix()))
+}
+
+// reject records an ingest whose groups failed validation. A nil IngestMetrics records nothing.
+func (m *IngestMetrics) reject() {
+	if m != nil {
+		m.Rejections.Inc()
+	}
+}
+
+// LocatorMetrics holds the prometheus collectors for a Locator's placements.
+type LocatorMetrics struct {
+	// Groups is the current number of groups.
+	Groups prometheus.Gauge
+
+	// Endpoints is the current number of endpoints objects are placed onto, by group.
+	Endpoints *prometheus.GaugeVec
+
+	// VNodes is the configured number of virtual nodes for ring placements.
+	VNodes prometheus.Gauge
+
+	// Updates counts the number of times placements were rebuilt.
+	Updates prometheus.Counter
+}
+
+// NewLocatorMetrics creates the locator metrics and registers them with the given Registerer.
+func NewLocatorMetrics(r prometheus.Registerer) (m *LocatorMetrics, err error) {
+	m = &LocatorMetrics{
+		Groups: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "hashy",
+			Subsystem: "locator",
+			Name:      "groups",
+			Help:      "The current number of groups.",
+		}),
+		Endpoints: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "hashy",
+				Subsystem: "locator",
+				Name:      "endpoints",
+				Help:      "The current number of endpoints objects are placed onto, by group.",
+			},
+			[]string{"group"},
+		),
+		VNodes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "hashy",
+			Subsystem: "locator",
+			Name:      "vnodes",
+			Help:      "The configured number of virtual nodes for ring placements.",
+		}),
+		Updates: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "hashy",
+			Subsystem: "locator",
+			Name:      "updates_total",
+			Help:      "The number of times placements were rebuilt.",
+		}),
+	}
+
+	if err = registerAll(r, m.Groups, m.Endpoints, m.VNodes, m.Updates); err != nil {
+		m = nil
+	}
+
+	return
+}
@@ -0,0 +1,509 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"go.uber.org/zap"
+)
+
+// overlayTypes are the RR types that an Overlay can change. These are the
+// types that hashy builds groups from.
+var overlayTypes = map[uint16]bool{
+	dns.TypeSRV:  true,
+	dns.TypeA:    true,
+	dns.TypeAAAA: true,
+	dns.TypeTXT:  true,
+}
+
+// The operations that can appear in a journal. Each line of a journal
+// is an operation followed by its arguments.
+const (
+	journalAdd         = "add"
+	journalDelete      = "delete"
+	journalDeleteRRset = "delete-rrset"
+	journalDeleteName  = "delete-name"
+)
+
+// rrsetKey identifies an RRset by canonical name and type.
+type rrsetKey struct {
+	name   string
+	rrType uint16
+}
+
+// journalEntry is a single change to an Overlay.
+type journalEntry struct {
+	op     string
+	rr     dns.RR
+	name   string
+	rrType uint16
+}
+
+// newJournalEntry interprets a single RR from the update section of an RFC 2136 UPDATE.
+// Class IN adds an RR, class ANY deletes an RRset or, for type ANY, every RRset with a name,
+// and class NONE deletes a single RR.
+func newJournalEntry(rr dns.RR) (e journalEntry, err error) {
+	var (
+		header = rr.Header()
+		rrType = dns.RRToType(rr)
+	)
+
+	switch {
+	case header.Class == dns.ClassANY && rrType == dns.TypeANY:
+		e = journalEntry{op: journalDeleteName, name: dnsutil.Canonical(header.Name)}
+
+	case !overlayTypes[rrType]:
+		err = fmt.Errorf("unsupported update type %s for %s", dns.TypeToString[rrType], header.Name)
+
+	case header.Class == dns.ClassINET:
+		e = journalEntry{op: journalAdd, rr: rr.Clone()}
+
+	case header.Class == dns.ClassANY:
+		e = journalEntry{op: journalDeleteRRset, name: dnsutil.Canonical(header.Name), rrType: rrType}
+
+	case header.Class == dns.ClassNONE:
+		e = journalEntry{op: journalDelete, rr: rr.Clone()}
+		e.rr.Header().Class = dns.ClassINET
+		e.rr.Header().TTL = 0
+
+	default:
+		err = fmt.Errorf("unsupported update class %d for %s", header.Class, header.Name)
+	}
+
+	return
+}
+
+// parseJournalEntry parses a single line of a journal.
+func parseJournalEntry(line string) (e journalEntry, err error) {
+	var args string
+	e.op, args, _ = strings.Cut(line, " ")
+	switch e.op {
+	case journalAdd, journalDelete:
+		e.rr, err = dns.New(args)
+		if err == nil && e.rr == nil {
+			err = errors.New("missing RR")
+		}
+
+	case journalDeleteRRset:
+		fields := strings.Fields(args)
+		if len(fields) != 2 {
+			err = errors.New("expected a name and a type")
+		} else if rrType, ok := dns.StringToType[fields[1]]; !ok {
+			err = fmt.Errorf("unknown type %s", fields[1])
+		} else {
+			e.name, e.rrType = dnsutil.Canonical(fields[0]), rrType
+		}
+
+	case journalDeleteName:
+		e.name = dnsutil.Canonical(strings.TrimSpace(args))
+
+	default:
+		err = fmt.Errorf("unknown operation %q", e.op)
+	}
+
+	return
+}
+
+// String returns the journal line for this entry, without a trailing newline.
+func (e journalEntry) String() string {
+	switch e.op {
+	case journalAdd, journalDelete:
+		return e.op + " " + e.rr.String()
+
+	case journalDeleteRRset:
+		return e.op + " " + e.name + " " + dns.TypeToString[e.rrType]
+
+	default:
+		return e.op + " " + e.name
+	}
+}
+
+type OverlayOption interface {
+	applyToOverlay(*Overlay) error
+}
+
+type overlayOptionFunc func(*Overlay) error
+
+func (f overlayOptionFunc) applyToOverlay(o *Overlay) error { return f(o) }
+
+func WithOverlayLogger(base *zap.Logger) OverlayOption {
+	return overlayOptionFunc(func(o *Overlay) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		o.logger = base.Named("overlay")
+		return nil
+	})
+}
+
+// WithOverlayDiscoveryDomain sets the domain that group definitions belong to, which is
+// used to validate added TXT records. By default, DefaultDiscoveryDomain is used.
+func WithOverlayDiscoveryDomain(domain string) OverlayOption {
+	return overlayOptionFunc(func(o *Overlay) error {
+		if len(domain) > 0 {
+			o.discoveryDomain = dnsutil.Fqdn(domain)
+		} else {
+			o.discoveryDomain = ""
+		}
+
+		return nil
+	})
+}
+
+// WithJournal sets the file that an Overlay persists its changes to. By default,
+// changes are only held in memory.
+func WithJournal(path string) OverlayOption {
+	return overlayOptionFunc(func(o *Overlay) error {
+		o.journal = path
+		return nil
+	})
+}
+
+// Overlay holds dynamic changes, e.g. from DNS UPDATE messages, to the RRs that groups are
+// built from. An RRCollector merges an Overlay with the ingested RRs: added RRs are collected
+// along with the ingested RRs, while deleted RRs, RRsets, and names hide matching ingested RRs.
+//
+// A deleted RRset or name only hides the ingested RRs that existed when the deletion took
+// effect. The first ingest after such a deletion replaces it with deletions of the RRs it hid,
+// so records that a source adds to that RRset or name later on are not hidden.
+//
+// Changes are appended to an optional journal, which is replayed and compacted when an Overlay
+// is created so that changes survive restarts.
+type Overlay struct {
+	logger          *zap.Logger
+	journal         string
+	discoveryDomain string
+
+	lock          sync.RWMutex
+	updates       uint64
+	added         []dns.RR
+	deleted       []dns.RR
+	deletedRRsets map[rrsetKey]bool
+	deletedNames  map[string]bool
+}
+
+// NewOverlay creates an Overlay from a set of options. If a journal is configured, it is
+// replayed and then compacted.
+func NewOverlay(opts ...OverlayOption) (*Overlay, error) {
+	o := &Overlay{
+		deletedRRsets: make(map[rrsetKey]bool),
+		deletedNames:  make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		if err := opt.applyToOverlay(o); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.logger == nil {
+		o.logger = zap.NewNop()
+	}
+
+	if len(o.discoveryDomain) == 0 {
+		o.discoveryDomain = dnsutil.Fqdn(DefaultDiscoveryDomain)
+	}
+
+	if len(o.journal) > 0 {
+		if err := o.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// load replays this overlay's journal, if it exists, and then compacts it.
+func (o *Overlay) load() error {
+	file, err := os.Open(o.journal)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+
+		e, err := parseJournalEntry(text)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", o.journal, line, err)
+		}
+
+		o.apply(e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	o.logger.Info("journal replayed",
+		zap.String("journal", o.journal),
+		zap.Int("added", len(o.added)),
+		zap.Int("deleted", len(o.deleted)+len(o.deletedRRsets)+len(o.deletedNames)),
+	)
+
+	return o.compact()
+}
+
+// compact atomically replaces this overlay's journal with the minimal set of
+// entries that reproduce the current state.
+func (o *Overlay) compact() error {
+	temp, err := os.CreateTemp(filepath.Dir(o.journal), filepath.Base(o.journal)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(temp.Name()) // a no-op once renamed
+	err = o.writeTo(temp)
+	if err == nil {
+		err = temp.Sync()
+	}
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), o.journal)
+	}
+
+	return err
+}
+
+// entries produces the minimal sequence of entries that reproduce this overlay's
+// state. Deletions come first, since additions are never hidden by deletions.
+func (o *Overlay) entries() iter.Seq[journalEntry] {
+	return func(yield func(journalEntry) bool) {
+		for _, name := range slices.Sorted(maps.Keys(o.deletedNames)) {
+			if !yield(journalEntry{op: journalDeleteName, name: name}) {
+				return
+			}
+		}
+
+		rrsets := slices.SortedFunc(maps.Keys(o.deletedRRsets), func(k1, k2 rrsetKey) int {
+			return cmp.Or(strings.Compare(k1.name, k2.name), cmp.Compare(k1.rrType, k2.rrType))
+		})
+
+		for _, k := range rrsets {
+			if !yield(journalEntry{op: journalDeleteRRset, name: k.name, rrType: k.rrType}) {
+				return
+			}
+		}
+
+		for _, rr := range o.deleted {
+			if !yield(journalEntry{op: journalDelete, rr: rr}) {
+				return
+			}
+		}
+
+		for _, rr := range o.added {
+			if !yield(journalEntry{op: journalAdd, rr: rr}) {
+				return
+			}
+		}
+	}
+}
+
+// writeTo writes the compacted journal of this overlay. The lock must be held.
+func (o *Overlay) writeTo(w io.Writer) (err error) {
+	for e := range o.entries() {
+		if _, err = io.WriteString(w, e.String()+"\n"); err != nil {
+			break
+		}
+	}
+
+	return
+}
+
+// appendJournal appends entries to the journal, if one is configured. The lock must be held.
+func (o *Overlay) appendJournal(entries []journalEntry) error {
+	if len(o.journal) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(o.journal, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+
+	_, err = io.WriteString(file, b.String())
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// apply changes the in-memory state of this overlay. The lock must be held.
+func (o *Overlay) apply(e journalEntry) {
+	switch e.op {
+	case journalAdd:
+		o.deleted = slices.DeleteFunc(o.deleted, func(rr dns.RR) bool { return dns.Equal(rr, e.rr) })
+		if !slices.ContainsFunc(o.added, func(rr dns.RR) bool { return dns.Equal(rr, e.rr) }) {
+			o.added = append(o.added, e.rr)
+		}
+
+	case journalDelete:
+		o.added = slices.DeleteFunc(o.added, func(rr dns.RR) bool { return dns.Equal(rr, e.rr) })
+		if !slices.ContainsFunc(o.deleted, func(rr dns.RR) bool { return dns.Equal(rr, e.rr) }) {
+			o.deleted = append(o.deleted, e.rr)
+		}
+
+	case journalDeleteRRset:
+		o.added = slices.DeleteFunc(o.added, func(rr dns.RR) bool {
+			return dnsutil.Canonical(rr.Header().Name) == e.name && dns.RRToType(rr) == e.rrType
+		})
+
+		o.deletedRRsets[rrsetKey{name: e.name, rrType: e.rrType}] = true
+
+	case journalDeleteName:
+		o.added = slices.DeleteFunc(o.added, func(rr dns.RR) bool {
+			return dnsutil.Canonical(rr.Header().Name) == e.name
+		})
+
+		o.deletedNames[e.name] = true
+	}
+}
+
+// Update applies the update section of an RFC 2136 UPDATE message. Class IN adds an RR,
+// class ANY deletes an RRset or, with type ANY, all RRsets for a name, and class NONE
+// deletes a single RR. Only SRV, A, AAAA, and TXT records can be changed.
+//
+// The updates are validated and journaled before any are applied, so either every
+// update is applied or none are. Added TXT records must hold valid group definitions
+// or endpoint attributes.
+func (o *Overlay) Update(updates []dns.RR) error {
+	var (
+		entries   = make([]journalEntry, 0, len(updates))
+		validator = RRCollector{discoveryDomain: o.discoveryDomain}
+	)
+
+	for _, rr := range updates {
+		e, err := newJournalEntry(rr)
+		if err == nil && e.op == journalAdd {
+			err = validator.addRR(e.rr)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, e)
+	}
+
+	defer o.lock.Unlock()
+	o.lock.Lock()
+
+	if err := o.appendJournal(entries); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		o.logger.Info("update", zap.Stringer("entry", e))
+		o.apply(e)
+	}
+
+	o.updates++
+	return nil
+}
+
+// version returns the number of updates applied to this overlay, which identifies
+// the deletions that hides consults.
+func (o *Overlay) version() uint64 {
+	defer o.lock.RUnlock()
+	o.lock.RLock()
+	return o.updates
+}
+
+// scope replaces the deleted RRsets and names with deletions of the ingested RRs they hid,
+// given every ingested RR that this overlay hid. Nothing is changed if the overlay was
+// updated since the given version, since hidden may not reflect the newer deletions.
+// The journal, if any, is compacted to hold the scoped deletions.
+func (o *Overlay) scope(version uint64, hidden []dns.RR) error {
+	defer o.lock.Unlock()
+	o.lock.Lock()
+
+	if o.updates != version || (len(o.deletedNames) == 0 && len(o.deletedRRsets) == 0) {
+		return nil
+	}
+
+	for _, rr := range hidden {
+		name := dnsutil.Canonical(rr.Header().Name)
+		if o.deletedNames[name] || o.deletedRRsets[rrsetKey{name: name, rrType: dns.RRToType(rr)}] {
+			d := rr.Clone()
+			d.Header().TTL = 0
+			if !slices.ContainsFunc(o.deleted, func(rr dns.RR) bool { return dns.Equal(rr, d) }) {
+				o.deleted = append(o.deleted, d)
+			}
+		}
+	}
+
+	clear(o.deletedNames)
+	clear(o.deletedRRsets)
+	o.logger.Info("deletions scoped", zap.Int("deleted", len(o.deleted)))
+	if len(o.journal) > 0 {
+		return o.compact()
+	}
+
+	return nil
+}
+
+// hides tests if an ingested RR is deleted by this overlay.
+func (o *Overlay) hides(rr dns.RR) bool {
+	defer o.lock.RUnlock()
+	o.lock.RLock()
+
+	name := dnsutil.Canonical(rr.Header().Name)
+	return o.deletedNames[name] ||
+		o.deletedRRsets[rrsetKey{name: name, rrType: dns.RRToType(rr)}] ||
+		slices.ContainsFunc(o.deleted, func(d dns.RR) bool { return dns.Equal(d, rr) })
+}
+
+// RRs returns a snapshot of the RRs added by this overlay.
+func (o *Overlay) RRs() iter.Seq[dns.RR] {
+	o.lock.RLock()
+	added := slices.Clone(o.added)
+	o.lock.RUnlock()
+
+	return slices.Values(added)
+}
+
+// checksum writes the state of this overlay in journal form, so that changes
+// to the overlay are detected along with changes to ingested RRs.
+func (o *Overlay) checksum(w io.Writer) error {
+	defer o.lock.RUnlock()
+	o.lock.RLock()
+	return o.writeTo(w)
+}
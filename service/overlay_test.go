@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"codeberg.org/miekg/dns"
+)
+
+// newTestUpdate creates an RR for the update section of an UPDATE, with the given class.
+func newTestUpdate(t *testing.T, s string, class uint16) dns.RR {
+	rr := newTestRR(t, s)
+	rr.Header().Class = class
+	return rr
+}
+
+// newTestDeleteRRset creates an update that deletes an RRset, or every RRset of a name
+// for the ANY type.
+func newTestDeleteRRset(name string, rr dns.RR) dns.RR {
+	rr.Header().Name, rr.Header().Class, rr.Header().TTL = name, dns.ClassANY, 0
+	return rr
+}
+
+// overlayAdded returns the RRs an overlay adds as strings.
+func overlayAdded(o *Overlay) (added []string) {
+	for rr := range o.RRs() {
+		added = append(added, rr.String())
+	}
+
+	slices.Sort(added)
+	return
+}
+
+func TestOverlayUpdate(t *testing.T) {
+	var (
+		a1   = newTestRR(t, "e1.example.org. 60 IN A 192.0.2.1")
+		a2   = newTestRR(t, "e1.example.org. 60 IN A 192.0.2.2")
+		aaaa = newTestRR(t, "e1.example.org. 60 IN AAAA 2001:db8::1")
+		srv  = newTestRR(t, "_test._tcp.example.org. 60 IN SRV 0 1 8080 e1.example.org.")
+		a3   = newTestRR(t, "e3.example.org. 60 IN A 192.0.2.3")
+	)
+
+	testCases := []struct {
+		name      string
+		updates   []dns.RR
+		err       bool
+		added     []dns.RR
+		hidden    []dns.RR
+		notHidden []dns.RR
+	}{
+		{
+			name:      "class IN adds an RR",
+			updates:   []dns.RR{newTestUpdate(t, "e3.example.org. 60 IN A 192.0.2.3", dns.ClassINET)},
+			added:     []dns.RR{a3},
+			notHidden: []dns.RR{a1, a2, aaaa, srv},
+		},
+		{
+			name:      "class NONE deletes an RR",
+			updates:   []dns.RR{newTestUpdate(t, "e1.example.org. 0 IN A 192.0.2.1", dns.ClassNONE)},
+			hidden:    []dns.RR{a1},
+			notHidden: []dns.RR{a2, aaaa, srv},
+		},
+		{
+			name:      "class ANY deletes an RRset",
+			updates:   []dns.RR{newTestDeleteRRset("e1.example.org.", &dns.A{})},
+			hidden:    []dns.RR{a1, a2},
+			notHidden: []dns.RR{aaaa, srv},
+		},
+		{
+			name:      "class and type ANY delete a name",
+			updates:   []dns.RR{newTestDeleteRRset("e1.example.org.", &dns.ANY{})},
+			hidden:    []dns.RR{a1, a2, aaaa},
+			notHidden: []dns.RR{srv, a3},
+		},
+		{
+			name: "deleting an added RR",
+			updates: []dns.RR{
+				newTestUpdate(t, "e3.example.org. 60 IN A 192.0.2.3", dns.ClassINET),
+				newTestUpdate(t, "e3.example.org. 0 IN A 192.0.2.3", dns.ClassNONE),
+			},
+			hidden: []dns.RR{a3},
+		},
+		{
+			name: "unsupported type",
+			updates: []dns.RR{
+				newTestUpdate(t, "e3.example.org. 60 IN A 192.0.2.3", dns.ClassINET),
+				newTestUpdate(t, "example.org. 60 IN MX 10 mail.example.org.", dns.ClassINET),
+			},
+			err:       true,
+			notHidden: []dns.RR{a1, a3},
+		},
+		{
+			name:    "unsupported class",
+			updates: []dns.RR{newTestUpdate(t, "e3.example.org. 60 IN A 192.0.2.3", dns.ClassCHAOS)},
+			err:     true,
+		},
+		{
+			name:    "invalid TXT",
+			updates: []dns.RR{newTestUpdate(t, `e1.example.org. 60 IN TXT "weight=heavy"`, dns.ClassINET)},
+			err:     true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			o, err := NewOverlay()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = o.Update(testCase.updates)
+			switch {
+			case testCase.err && err == nil:
+				t.Fatal("expected an error")
+
+			case !testCase.err && err != nil:
+				t.Fatalf("unexpected error: %s", err)
+
+			case testCase.err && (o.version() != 0 || len(overlayAdded(o)) > 0):
+				t.Fatal("a failed update must not change the overlay")
+			}
+
+			var expected []string
+			for _, rr := range testCase.added {
+				expected = append(expected, rr.String())
+			}
+
+			slices.Sort(expected)
+			if added := overlayAdded(o); !slices.Equal(added, expected) {
+				t.Errorf("expected %v to be added, got %v", expected, added)
+			}
+
+			for _, rr := range testCase.hidden {
+				if !o.hides(rr) {
+					t.Errorf("expected %s to be hidden", rr)
+				}
+			}
+
+			for _, rr := range testCase.notHidden {
+				if o.hides(rr) {
+					t.Errorf("expected %s not to be hidden", rr)
+				}
+			}
+		})
+	}
+}
+
+func TestOverlayJournal(t *testing.T) {
+	var (
+		journal = filepath.Join(t.TempDir(), "overlay.journal")
+		a1      = newTestRR(t, "e1.example.org. 60 IN A 192.0.2.1")
+		a2      = newTestRR(t, "e1.example.org. 60 IN A 192.0.2.2")
+		aaaa    = newTestRR(t, "e2.example.org. 60 IN AAAA 2001:db8::2")
+		srv     = newTestRR(t, "_test._tcp.example.org. 60 IN SRV 0 1 8080 e2.example.org.")
+	)
+
+	o, err := NewOverlay(WithJournal(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates := [][]dns.RR{
+		{newTestUpdate(t, "e3.example.org. 60 IN A 192.0.2.3", dns.ClassINET)},
+		{newTestUpdate(t, "e4.example.org. 60 IN A 192.0.2.4", dns.ClassINET)},
+		{newTestUpdate(t, "e4.example.org. 0 IN A 192.0.2.4", dns.ClassNONE)},
+		{newTestUpdate(t, "e1.example.org. 0 IN A 192.0.2.1", dns.ClassNONE)},
+		{newTestDeleteRRset("e2.example.org.", &dns.ANY{})},
+	}
+
+	for _, u := range updates {
+		if err := o.Update(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a restart replays the journal into the same state
+	replayed, err := NewOverlay(WithJournal(journal))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if added, expected := overlayAdded(replayed), overlayAdded(o); !slices.Equal(added, expected) {
+		t.Errorf("expected %v to be added after a restart, got %v", expected, added)
+	}
+
+	for _, rr := range []dns.RR{a1, aaaa, newTestRR(t, "e4.example.org. 60 IN A 192.0.2.4")} {
+		if !replayed.hides(rr) {
+			t.Errorf("expected %s to be hidden after a restart", rr)
+		}
+	}
+
+	for _, rr := range []dns.RR{a2, srv} {
+		if replayed.hides(rr) {
+			t.Errorf("expected %s not to be hidden after a restart", rr)
+		}
+	}
+
+	// the replay compacted the journal, so the add of e4 is gone and only its deletion remains
+	data, err := os.ReadFile(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var compacted strings.Builder
+	if err := replayed.writeTo(&compacted); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(string(data), "\n"); string(data) != compacted.String() || lines != 4 {
+		t.Errorf("unexpected compacted journal:\n%s", data)
+	}
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+const (
+	// MaxWeightScale is the largest number of times a single endpoint is placed within a group.
+	// Weights that would require more placements are scaled down proportionally.
+	MaxWeightScale = 32
+
+	// AlgorithmAttribute is the group attribute that selects a group's placement algorithm.
+	AlgorithmAttribute = "algorithm"
+)
+
+// Algorithm is the name of a strategy for placing objects onto a group's endpoints.
+type Algorithm string
+
+const (
+	// AlgorithmRing is a consistent hash ring with virtual nodes. This is the default.
+	AlgorithmRing Algorithm = "ring"
+
+	// AlgorithmRendezvous is highest random weight (HRW) hashing. It uses no extra memory
+	// and moves the fewest objects when endpoints change, but lookups are linear in the
+	// number of endpoints.
+	AlgorithmRendezvous Algorithm = "rendezvous"
+
+	// AlgorithmJump is jump consistent hashing. It uses no extra memory and has fast lookups,
+	// but removing any endpoint other than the last (by name) from a group moves many objects.
+	// Endpoints that are unavailable or outside the best priority tier keep their buckets.
+	AlgorithmJump Algorithm = "jump"
+
+	// AlgorithmMaglev is Maglev hashing. It uses a fixed size lookup table for constant time
+	// lookups and an even spread, at the cost of slightly more objects moving when endpoints change.
+	AlgorithmMaglev Algorithm = "maglev"
+
+	// DefaultAlgorithm is the algorithm used when none is configured.
+	DefaultAlgorithm = AlgorithmRing
+)
+
+// ParseAlgorithm validates the name of an algorithm. A blank name is DefaultAlgorithm.
+func ParseAlgorithm(v string) (Algorithm, error) {
+	switch a := Algorithm(v); a {
+	case "":
+		return DefaultAlgorithm, nil
+
+	case AlgorithmRing, AlgorithmRendezvous, AlgorithmJump, AlgorithmMaglev:
+		return a, nil
+
+	default:
+		return "", fmt.Errorf("unknown algorithm: %s", v)
+	}
+}
+
+// objectKey is an object being located. This allows both []byte and string objects
+// to be located without extra allocations.
+type objectKey struct {
+	bytes    []byte
+	str      string
+	isString bool
+}
+
+// hash computes a 64-bit FNV-1a hash of this object, which is stable across processes.
+func (ok objectKey) hash() uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	h := uint64(offset64)
+	if ok.isString {
+		for i := 0; i < len(ok.str); i++ {
+			h ^= uint64(ok.str[i])
+			h *= prime64
+		}
+	} else {
+		for _, b := range ok.bytes {
+			h ^= uint64(b)
+			h *= prime64
+		}
+	}
+
+	return mix64(h)
+}
+
+// hashString computes the same hash as objectKey for a string.
+func hashString(s string) uint64 {
+	return objectKey{str: s, isString: true}.hash()
+}
+
+// mix64 is the splitmix64 finalizer, which spreads the bits of a hash. This is used
+// to combine hashes for the algorithms that need independent hash values.
+func mix64(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// placement is the common interface for placement algorithms. A placement locates
+// objects onto a single group's endpoints.
+type placement interface {
+	// Len returns the number of endpoints objects can be placed onto.
+	Len() int
+
+	// appendNearest appends up to n distinct endpoints for an object, in order of
+	// preference, to dst. The first endpoint appended must be the same regardless of n.
+	appendNearest(dst LocatedEndpoints, n int, key objectKey) LocatedEndpoints
+
+	// token describes the position an object matched within this placement, e.g. the
+	// vnode, bucket, or slot. This is only used to explain placements.
+	token(key objectKey) string
+}
+
+// member is a single placement of an endpoint within a group. Weighted endpoints
+// have several members, each with a distinct id.
+type member struct {
+	id       string
+	hash     uint64
+	endpoint *Endpoint
+}
+
+// newMembers produces the members for a set of endpoints. Each endpoint has members
+// in proportion to its weight, relative to the other endpoints. Weights are reduced by
+// their greatest common divisor and, when the largest would still need more than
+// MaxWeightScale members, scaled down proportionally.
+//
+// An endpoint's first member always uses its original name, so a group whose endpoints
+// all have the same weight hashes exactly as it would without weights.
+func newMembers(endpoints []*Endpoint) (members []*member) {
+	var (
+		divisor  uint64
+		maxUnits uint64
+	)
+
+	// a weight of zero still gets a single placement
+	units := func(e *Endpoint) uint64 { return max(uint64(e.Weight()), 1) }
+	for _, e := range endpoints {
+		divisor = gcd(divisor, units(e))
+		maxUnits = max(maxUnits, units(e))
+	}
+
+	members = make([]*member, 0, len(endpoints))
+	for _, e := range endpoints {
+		copies := units(e) / divisor
+		if maxUnits/divisor > MaxWeightScale {
+			copies = max((units(e)*MaxWeightScale+maxUnits/2)/maxUnits, 1)
+		}
+
+		for i := range copies {
+			id := e.OriginalName()
+			if i > 0 {
+				id += "#" + strconv.FormatUint(i, 10)
+			}
+
+			members = append(members, &member{
+				id:       id,
+				hash:     hashString(id),
+				endpoint: e,
+			})
+		}
+	}
+
+	return
+}
+
+// gcd computes the greatest common divisor of two integers. The gcd of
+// zero (0) and any integer is that integer.
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// groupMembers returns the members of every endpoint in a group, along with the endpoints
+// that objects may be placed onto. Only the available endpoints with the best SRV priority
+// are placed. When no endpoint in a priority tier is available, the next tier is used.
+func groupMembers(g *Group, a Availability) (members []*member, placed map[*Endpoint]bool) {
+	all := slices.Collect(g.Endpoints())
+	members = newMembers(all)
+	placed = make(map[*Endpoint]bool, len(all))
+	if tier, found := bestTier(all, a); found {
+		for _, e := range all {
+			if e.Priority() == tier && a.Available(e) {
+				placed[e] = true
+			}
+		}
+	}
+
+	return
+}
+
+// placedMembers returns the members of a group that objects may be placed onto.
+func placedMembers(g *Group, a Availability) []*member {
+	// members are computed across the whole group, so that changes in tiers
+	// or availability never shift the remaining endpoints
+	members, placed := groupMembers(g, a)
+	return slices.DeleteFunc(members, func(m *member) bool {
+		return !placed[m.endpoint]
+	})
+}
+
+// distinctEndpoints counts the distinct endpoints in a set of members.
+func distinctEndpoints(members []*member) int {
+	seen := make(map[*Endpoint]bool, len(members))
+	for _, m := range members {
+		seen[m.endpoint] = true
+	}
+
+	return len(seen)
+}
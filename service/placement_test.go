@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/xmidt-org/hashy/config"
+)
+
+// newWeightedGroups creates a group named "test" with a placement algorithm, whose endpoints
+// have the given SRV weights.
+func newWeightedGroups(t *testing.T, algorithm Algorithm, weights ...uint16) *Groups {
+	t.Helper()
+	var endpoints []config.StaticEndpoint
+	for i, weight := range weights {
+		endpoints = append(endpoints, config.StaticEndpoint{
+			Name:      "e" + strconv.Itoa(i) + ".example.org.",
+			Addresses: []string{"192.0.2." + strconv.Itoa(i+1)},
+			Port:      8080,
+			Weight:    weight,
+		})
+	}
+
+	return newTestGroups(t, config.StaticGroup{
+		Name:       "test",
+		Attributes: map[string]string{AlgorithmAttribute: string(algorithm)},
+		Services: []config.StaticService{
+			{Name: "_test._tcp.example.org.", Endpoints: endpoints},
+		},
+	})
+}
+
+func TestNewMembers(t *testing.T) {
+	testCases := []struct {
+		name    string
+		weights []uint16
+		copies  []int
+	}{
+		{name: "equal", weights: []uint16{10, 10, 10}, copies: []int{1, 1, 1}},
+		{name: "zero", weights: []uint16{0, 1}, copies: []int{1, 1}},
+		{name: "divisor", weights: []uint16{200, 100}, copies: []int{2, 1}},
+		{name: "small", weights: []uint16{3, 2, 1}, copies: []int{3, 2, 1}},
+		{name: "scaled", weights: []uint16{2001, 1000}, copies: []int{MaxWeightScale, MaxWeightScale / 2}},
+		{name: "scaled above the cap", weights: []uint16{6400, 3200, 1}, copies: []int{MaxWeightScale, MaxWeightScale / 2, 1}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			endpoints := slices.Collect(newWeightedGroups(t, AlgorithmRing, testCase.weights...).Get("test").Endpoints())
+			copies := make(map[*Endpoint]int, len(endpoints))
+			for _, m := range newMembers(endpoints) {
+				copies[m.endpoint]++
+			}
+
+			for _, e := range endpoints {
+				i := slices.IndexFunc(testCase.weights, func(weight uint16) bool { return e.Weight() == weight })
+				if copies[e] != testCase.copies[i] {
+					t.Errorf("expected %d members for %s, got %d", testCase.copies[i], e.OriginalName(), copies[e])
+				}
+			}
+		})
+	}
+}
+
+func TestWeightShare(t *testing.T) {
+	const objects = 20000
+	for _, algorithm := range []Algorithm{AlgorithmRing, AlgorithmRendezvous, AlgorithmJump, AlgorithmMaglev} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			loc, err := NewLocator()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			loc.Update(newWeightedGroups(t, algorithm, 4001, 2000))
+			counts := make(map[uint16]int)
+			for i := range objects {
+				located := loc.FindString("mac:"+strconv.Itoa(i), "test")
+				counts[located[0].Weight()]++
+			}
+
+			if ratio := float64(counts[4001]) / float64(counts[2000]); math.Abs(ratio-2) > 0.3 {
+				t.Errorf("expected about a 2:1 share, got %d:%d", counts[4001], counts[2000])
+			}
+		})
+	}
+}
@@ -5,44 +5,254 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/xmidt-org/hashy/config"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
+// configuredSources returns the sources declared in configuration. Outside of groups.sources,
+// the single kind of source that is configured, if any, becomes the only source.
+func configuredSources(gcfg config.Groups) []config.Source {
+	switch {
+	case len(gcfg.Sources) > 0:
+		return gcfg.Sources
+
+	case len(gcfg.ZoneFiles) > 0:
+		return []config.Source{{Type: SourceTypeZoneFiles, ZoneFiles: gcfg.ZoneFiles}}
+
+	case len(gcfg.Documents) > 0:
+		return []config.Source{{Type: SourceTypeDocuments, Documents: gcfg.Documents}}
+
+	case len(gcfg.Transfers) > 0:
+		return []config.Source{{Type: SourceTypeTransfer, Transfers: gcfg.Transfers}}
+
+	case len(gcfg.Resolvers) > 0:
+		return []config.Source{{Type: SourceTypeResolver, Resolvers: gcfg.Resolvers}}
+
+	default:
+		return nil
+	}
+}
+
+// newCompositeIngester creates a CompositeIngester for the sources declared in configuration.
+// Any static groups are the last source, so every other source takes precedence over them.
+func newCompositeIngester(base *zap.Logger, gcfg config.Groups, m *IngestMetrics, tp trace.TracerProvider, overlay *Overlay, listeners []IngestListener, validators []IngestValidator) (*CompositeIngester, error) {
+	opts := []CompositeIngesterOption{
+		WithCompositeLogger(base),
+		WithCompositeMetrics(m),
+		WithCompositeTracerProvider(tp),
+		WithCompositeGroupsConfig(gcfg),
+		WithCompositeOverlay(overlay),
+		WithCompositeListeners(listeners...),
+		WithCompositeValidators(validators...),
+	}
+
+	for i, scfg := range configuredSources(gcfg) {
+		name := scfg.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("%s-%d", scfg.Type, i)
+		}
+
+		s, err := NewRRSource(base.With(zap.String("source", name)), tp, gcfg, scfg)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", name, err)
+		}
+
+		opts = append(opts, WithSource(name, s))
+	}
+
+	if len(gcfg.Static) > 0 {
+		s, err := NewStaticSource(gcfg.DiscoveryDomain, gcfg.DefaultTTL, gcfg.Static)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, WithSource(StaticSourceName, s))
+	}
+
+	return NewCompositeIngester(opts...)
+}
+
 // Provide creates the relevant components in this package.
 func Provide() fx.Option {
 	return fx.Options(
 		fx.Provide(
 			fx.Annotate(
-				func(base *zap.Logger, gcfg config.Groups) (loc *Locator, lis IngestListener, err error) {
+				func(base *zap.Logger, gcfg config.Groups, r prometheus.Registerer) (loc *Locator, lis IngestListener, hl HealthListener, err error) {
+					var m *LocatorMetrics
+					if m, err = NewLocatorMetrics(r); err != nil {
+						return
+					}
+
 					loc, err = NewLocator(
 						WithLocatorLogger(base),
+						WithLocatorMetrics(m),
+						WithAlgorithm(gcfg.Algorithm),
 						WithVNodes(gcfg.VNodes),
+						WithReplicas(gcfg.Replicas),
+						WithLoadFactor(gcfg.LoadFactor),
 					)
 
 					if err == nil {
 						lis = loc
+						hl = loc
 					}
 
 					return
 				},
-				fx.ResultTags("", `group:"ingestListeners"`),
+				fx.ResultTags("", `group:"ingestListeners"`, `group:"healthListeners"`),
 			),
 			fx.Annotate(
-				func(base *zap.Logger, gcfg config.Groups, listeners []IngestListener) (*FileIngester, error) {
-					return NewFileIngester(
-						WithIngestLogger(base),
-						WithGroupsConfig(gcfg),
-						WithIngestListeners(listeners...),
+				func(base *zap.Logger, hcfg config.Health, listeners []HealthListener, lc fx.Lifecycle) (hc *HealthChecker, lis []IngestListener, err error) {
+					var probe HealthProbe
+					switch hcfg.Probe {
+					case "":
+						// health checking is disabled
+						return
+
+					case "tcp":
+						probe = TCPProbe{}
+
+					case "http":
+						probe = HTTPProbe{
+							Scheme: hcfg.Scheme,
+							Path:   hcfg.Path,
+						}
+
+					default:
+						err = fmt.Errorf("unknown health probe: %s", hcfg.Probe)
+						return
+					}
+
+					hc, err = NewHealthChecker(
+						WithHealthLogger(base),
+						WithHealthProbe(probe),
+						WithHealthInterval(hcfg.Interval),
+						WithHealthTimeout(hcfg.Timeout),
+						WithHealthThresholds(hcfg.UnhealthyThreshold, hcfg.HealthyThreshold),
+						WithHealthListeners(listeners...),
+					)
+
+					if err == nil {
+						lis = append(lis, hc)
+						lc.Append(fx.StartStopHook(
+							hc.Start,
+							hc.Stop,
+						))
+					}
+
+					return
+				},
+				fx.ParamTags("", "", `group:"healthListeners"`),
+				fx.ResultTags("", `group:"ingestListeners,flatten"`),
+			),
+			// create the snapshot store, which is nil unless a snapshot is configured
+			fx.Annotate(
+				func(base *zap.Logger, gcfg config.Groups) (ss *SnapshotStore, lis []IngestListener, err error) {
+					if len(gcfg.Snapshot) == 0 {
+						return
+					}
+
+					ss, err = NewSnapshotStore(
+						WithSnapshotLogger(base),
+						WithSnapshotPath(gcfg.Snapshot),
 					)
+
+					if err == nil {
+						lis = append(lis, ss)
+					}
+
+					return
 				},
-				fx.ParamTags("", "", `group:"ingestListeners"`),
+				fx.ResultTags("", `group:"ingestListeners,flatten"`),
 			),
-			func(gcfg config.Groups, fi *FileIngester, lc fx.Lifecycle) (ic *IngestChecker, err error) {
+			func(base *zap.Logger, gcfg config.Groups, ucfg config.Update) (*Overlay, error) {
+				return NewOverlay(
+					WithOverlayLogger(base),
+					WithOverlayDiscoveryDomain(gcfg.DiscoveryDomain),
+					WithJournal(ucfg.Journal),
+				)
+			},
+			func(r prometheus.Registerer) (*IngestMetrics, error) {
+				return NewIngestMetrics(r)
+			},
+			// create the ingester for the configured source of groups, which is one of
+			// zone files, group documents, zone transfers, resolvers, or several sources merged together.
+			// Static groups from configuration are merged with whichever source is configured. Besides the configured validators,
+			// other components may validate groups through the ingestValidators group.
+			fx.Annotate(
+				func(base *zap.Logger, gcfg config.Groups, m *IngestMetrics, tp trace.TracerProvider, overlay *Overlay, listeners []IngestListener, validators []IngestValidator) (StatusIngester, error) {
+					sources := 0
+					for _, configured := range []bool{len(gcfg.ZoneFiles) > 0, len(gcfg.Documents) > 0, len(gcfg.Transfers) > 0, len(gcfg.Resolvers) > 0, len(gcfg.Sources) > 0} {
+						if configured {
+							sources++
+						}
+					}
+
+					switch {
+					case sources > 1:
+						return nil, errors.New("only one of zone files, documents, zone transfers, resolvers, or sources may be configured")
+
+					case len(gcfg.Sources) > 0 || len(gcfg.Static) > 0:
+						// static groups are merged with the configured source, just like several sources
+						return newCompositeIngester(base, gcfg, m, tp, overlay, listeners, validators)
+
+					case len(gcfg.Documents) > 0:
+						return NewDocumentIngester(
+							WithDocumentLogger(base),
+							WithDocumentMetrics(m),
+							WithDocumentTracerProvider(tp),
+							WithDocumentGroupsConfig(gcfg),
+							WithDocumentOverlay(overlay),
+							WithDocumentListeners(listeners...),
+							WithDocumentValidators(validators...),
+						)
+
+					case len(gcfg.Transfers) > 0:
+						return NewTransferIngester(
+							WithTransferLogger(base),
+							WithTransferMetrics(m),
+							WithTransferTracerProvider(tp),
+							WithTransferGroupsConfig(gcfg),
+							WithTransferOverlay(overlay),
+							WithTransferListeners(listeners...),
+							WithTransferValidators(validators...),
+						)
+
+					case len(gcfg.Resolvers) > 0:
+						return NewResolverIngester(
+							WithResolverLogger(base),
+							WithResolverMetrics(m),
+							WithResolverTracerProvider(tp),
+							WithResolverGroupsConfig(gcfg),
+							WithResolverOverlay(overlay),
+							WithResolverListeners(listeners...),
+							WithResolverValidators(validators...),
+						)
+
+					default:
+						return NewFileIngester(
+							WithIngestLogger(base),
+							WithIngestMetrics(m),
+							WithIngestTracerProvider(tp),
+							WithGroupsConfig(gcfg),
+							WithOverlay(overlay),
+							WithIngestListeners(listeners...),
+							WithIngestValidators(validators...),
+						)
+					}
+				},
+				fx.ParamTags("", "", "", "", "", `group:"ingestListeners"`, `group:"ingestValidators"`),
+			),
+			func(gcfg config.Groups, si StatusIngester, lc fx.Lifecycle) (ic *IngestChecker, err error) {
 				ic, err = NewIngestChecker(
-					WithIngester(fi),
+					WithIngester(si),
 					WithCheckInterval(gcfg.CheckInterval),
 				)
 
@@ -55,14 +265,64 @@ func Provide() fx.Option {
 					ic.Stop,
 				))
 
+				return
+			},
+			// create the watcher for zone files and documents, which is nil unless watching is enabled
+			func(base *zap.Logger, gcfg config.Groups, ic *IngestChecker, lc fx.Lifecycle) (fw *FileWatcher, err error) {
+				if !gcfg.Watch {
+					return
+				}
+
+				globs := slices.Concat(gcfg.ZoneFiles, gcfg.Documents)
+				for _, scfg := range gcfg.Sources {
+					switch scfg.Type {
+					case SourceTypeZoneFiles:
+						globs = append(globs, scfg.ZoneFiles...)
+
+					case SourceTypeDocuments:
+						globs = append(globs, scfg.Documents...)
+					}
+				}
+
+				if len(globs) == 0 {
+					err = errors.New("watching requires zone files or documents")
+					return
+				}
+
+				fw, err = NewFileWatcher(
+					WithWatchLogger(base),
+					WithWatchGlobs(globs...),
+					WithWatchDebounce(gcfg.WatchDebounce),
+					WithWatchTrigger(ic),
+				)
+
+				if err == nil {
+					lc.Append(fx.StartStopHook(
+						fw.Start,
+						fw.Stop,
+					))
+				}
+
 				return
 			},
 		),
 		fx.Invoke(
-			func(fi *FileIngester) {
-				fi.Ingest(context.Background())
-			},
+			fx.Annotate(
+				func(ss *SnapshotStore, listeners []IngestListener, si StatusIngester) {
+					// the last known good groups are served until the first ingest succeeds,
+					// and that ingest is validated against them
+					if ss != nil {
+						if gps := ss.Seed(listeners...); gps != nil {
+							si.SeedAccepted(gps)
+						}
+					}
+
+					si.Ingest(context.Background())
+				},
+				fx.ParamTags("", `group:"ingestListeners"`),
+			),
 			func(*IngestChecker) {},
+			func(*FileWatcher) {},
 		),
 	)
 }
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// rendezvousPlacement is highest random weight (HRW) hashing. Each object is placed onto
+// the member with the highest score, where a score combines the object's hash with the
+// member's hash. Successors are simply the distinct endpoints with the next highest scores.
+type rendezvousPlacement struct {
+	members   []*member
+	endpoints int
+}
+
+func newRendezvousPlacement(members []*member) *rendezvousPlacement {
+	return &rendezvousPlacement{
+		members:   members,
+		endpoints: distinctEndpoints(members),
+	}
+}
+
+// Len returns the number of endpoints objects can be placed onto.
+func (rp *rendezvousPlacement) Len() int {
+	return rp.endpoints
+}
+
+// score computes the score of a member for a given object hash.
+func (rp *rendezvousPlacement) score(objectHash uint64, m *member) uint64 {
+	return mix64(objectHash ^ m.hash)
+}
+
+// best returns the member with the highest score for an object hash, along with that score.
+func (rp *rendezvousPlacement) best(objectHash uint64) (best *member, bestScore uint64) {
+	for _, m := range rp.members {
+		if score := rp.score(objectHash, m); best == nil || score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+
+	return
+}
+
+func (rp *rendezvousPlacement) appendNearest(dst LocatedEndpoints, n int, key objectKey) LocatedEndpoints {
+	if rp.Len() == 0 || n < 1 {
+		return dst
+	}
+
+	objectHash := key.hash()
+	if n == 1 {
+		// the common case, which needs no sorting
+		best, _ := rp.best(objectHash)
+		return append(dst, LocatedEndpoint{Endpoint: best.endpoint})
+	}
+
+	// an endpoint's score is the best score of any of its members
+	scores := make(map[*Endpoint]uint64, rp.endpoints)
+	for _, m := range rp.members {
+		if score := rp.score(objectHash, m); score >= scores[m.endpoint] {
+			scores[m.endpoint] = score
+		}
+	}
+
+	ranked := make([]*Endpoint, 0, len(scores))
+	for e := range scores {
+		ranked = append(ranked, e)
+	}
+
+	slices.SortFunc(ranked, func(e1, e2 *Endpoint) int {
+		return cmp.Or(
+			cmp.Compare(scores[e2], scores[e1]),
+			cmp.Compare(e1.OriginalName(), e2.OriginalName()),
+		)
+	})
+
+	for rank, e := range ranked[:min(n, len(ranked))] {
+		dst = append(dst, LocatedEndpoint{
+			Endpoint: e,
+			Rank:     rank,
+		})
+	}
+
+	return dst
+}
+
+// token returns the member with the highest score, along with that score.
+func (rp *rendezvousPlacement) token(key objectKey) string {
+	if rp.Len() == 0 {
+		return ""
+	}
+
+	best, score := rp.best(key.hash())
+	return fmt.Sprintf("member %s score %016x", best.id, score)
+}
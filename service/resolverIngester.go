@@ -0,0 +1,467 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/medley"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultResolverPort is the port used for a resolver that has no port.
+	DefaultResolverPort = "53"
+
+	// DefaultResolverTimeout is the default time allowed for each query sent to a resolver.
+	DefaultResolverTimeout = 5 * time.Second
+
+	// DefaultResolverMinTTL is the default lower bound on how long resolved records are cached,
+	// which prevents records with very small TTLs from being resolved continually.
+	DefaultResolverMinTTL = 5 * time.Second
+
+	// resolverUDPSize is the EDNS buffer size advertised to resolvers.
+	resolverUDPSize = 1232
+)
+
+// resolvedRRset is a cached answer from a resolver. An empty RRset is a negative answer.
+type resolvedRRset struct {
+	rrs     []dns.RR
+	expires time.Time
+}
+
+type ResolverIngesterOption interface {
+	applyToResolverIngester(*ResolverIngester) error
+}
+
+type resolverIngesterOptionFunc func(*ResolverIngester) error
+
+func (f resolverIngesterOptionFunc) applyToResolverIngester(ri *ResolverIngester) error { return f(ri) }
+
+func WithResolverLogger(base *zap.Logger) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		ri.logger = base.Named("resolverIngester")
+		return nil
+	})
+}
+
+// WithResolvers adds the addresses of DNS resolvers, as host or host:port. Resolvers are
+// tried in order until one answers.
+func WithResolvers(more ...string) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.resolvers = slices.Grow(ri.resolvers, len(more))
+		for _, r := range more {
+			if _, _, err := net.SplitHostPort(r); err != nil {
+				r = net.JoinHostPort(r, DefaultResolverPort)
+			}
+
+			ri.resolvers = append(ri.resolvers, r)
+		}
+
+		return nil
+	})
+}
+
+// WithResolverTimeout sets the time allowed for each query. If unset, DefaultResolverTimeout is used.
+func WithResolverTimeout(v time.Duration) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.timeout = v
+		return nil
+	})
+}
+
+// WithResolverMinTTL sets the least time that resolved records are cached. If unset,
+// DefaultResolverMinTTL is used.
+func WithResolverMinTTL(v time.Duration) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.minTTL = v
+		return nil
+	})
+}
+
+func WithResolverDiscoveryDomain(domain string) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		if len(domain) > 0 {
+			ri.discoveryDomain = dnsutil.Fqdn(domain)
+		} else {
+			ri.discoveryDomain = ""
+		}
+
+		return nil
+	})
+}
+
+func WithResolverListeners(more ...IngestListener) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.listeners = slices.Grow(ri.listeners, len(more))
+		ri.listeners = append(ri.listeners, more...)
+		return nil
+	})
+}
+
+// WithResolverValidators adds validators that the groups from each ingest must pass before they
+// are dispatched. Groups that fail are rejected, and an error event is dispatched instead.
+func WithResolverValidators(more ...IngestValidator) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.gate.validators = append(ri.gate.validators, more...)
+		return nil
+	})
+}
+
+// WithResolverMetrics sets the prometheus metrics a ResolverIngester records. By default, no metrics are recorded.
+func WithResolverMetrics(m *IngestMetrics) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.metrics = m
+		return nil
+	})
+}
+
+// WithResolverTracerProvider sets the OpenTelemetry provider a ResolverIngester uses to create
+// a span for each ingest, with a child span for each query sent to a resolver. By default, no spans are recorded.
+func WithResolverTracerProvider(tp trace.TracerProvider) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		if tp != nil {
+			ri.tracer = tp.Tracer(TracerName)
+		}
+
+		return nil
+	})
+}
+
+// WithResolverOverlay sets the Overlay of dynamic changes merged with the resolved records.
+func WithResolverOverlay(o *Overlay) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) error {
+		ri.overlay = o
+		return nil
+	})
+}
+
+func WithResolverGroupsConfig(gcfg config.Groups) ResolverIngesterOption {
+	return resolverIngesterOptionFunc(func(ri *ResolverIngester) (err error) {
+		err = WithResolverDiscoveryDomain(gcfg.DiscoveryDomain).
+			applyToResolverIngester(ri)
+
+		if err == nil {
+			err = WithResolvers(gcfg.Resolvers...).
+				applyToResolverIngester(ri)
+		}
+
+		if err == nil {
+			err = WithResolverValidators(NewIngestValidators(gcfg.Validation)...).
+				applyToResolverIngester(ri)
+		}
+
+		return
+	})
+}
+
+// ResolverIngester discovers groups from live DNS. It resolves the TXT records of the discovery
+// domain, then the SRV records of each service those group definitions list, then the A, AAAA,
+// and TXT records of each SRV target.
+//
+// Answers are cached for their TTLs, so an Ingest only sends queries for records that have
+// expired. A ResolverIngester is an IngestScheduler, so an IngestChecker ingests again as soon
+// as the first cached record expires.
+type ResolverIngester struct {
+	ingestCore
+
+	resolvers []string
+	timeout   time.Duration
+	minTTL    time.Duration
+
+	// cache is updated by each Ingest while the ingest lock is held
+	cache      map[rrsetKey]resolvedRRset
+	nextIngest atomic.Pointer[time.Time]
+}
+
+// NewResolverIngester creates a ResolverIngester from a set of options. At least one resolver is required.
+func NewResolverIngester(opts ...ResolverIngesterOption) (*ResolverIngester, error) {
+	ri := &ResolverIngester{
+		cache: make(map[rrsetKey]resolvedRRset),
+	}
+
+	for _, o := range opts {
+		if err := o.applyToResolverIngester(ri); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ri.resolvers) == 0 {
+		return nil, errors.New("at least one resolver is required")
+	}
+
+	ri.initialize()
+	if ri.timeout <= 0 {
+		ri.timeout = DefaultResolverTimeout
+	}
+
+	if ri.minTTL <= 0 {
+		ri.minTTL = DefaultResolverMinTTL
+	}
+
+	return ri, nil
+}
+
+// NextIngest returns when the first record cached by the most recent Ingest expires.
+// Before the first Ingest completes, this method returns the zero time.
+func (ri *ResolverIngester) NextIngest() (next time.Time) {
+	if p := ri.nextIngest.Load(); p != nil {
+		next = *p
+	}
+
+	return
+}
+
+// exchange sends a query to each resolver in turn, retrying over TCP if an answer was truncated.
+func (ri *ResolverIngester) exchange(ctx context.Context, name string, qtype uint16) (response *dns.Msg, err error) {
+	client := dns.NewClient()
+	client.ReadTimeout = ri.timeout
+	client.WriteTimeout = ri.timeout
+
+	for _, resolver := range ri.resolvers {
+		for _, network := range []string{"udp", "tcp"} {
+			m := dns.NewMsg(name, qtype)
+			m.UDPSize = resolverUDPSize
+
+			queryCtx, cancel := context.WithTimeout(ctx, ri.timeout)
+			response, _, err = client.Exchange(queryCtx, m, network, resolver)
+			cancel()
+
+			if err != nil || !response.Truncated {
+				break
+			}
+		}
+
+		switch {
+		case err != nil:
+			ri.logger.Warn("resolver failed", zap.String("resolver", resolver), zap.Error(err))
+
+		case response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError:
+			err = fmt.Errorf("resolver %s answered %s for %s %s", resolver, dns.RcodeToString[response.Rcode], name, dns.TypeToString[qtype])
+			ri.logger.Warn("resolver failed", zap.String("resolver", resolver), zap.Error(err))
+
+		default:
+			return
+		}
+	}
+
+	return
+}
+
+// ttlOf returns how long an answer may be cached. Positive answers are cached for the least TTL of
+// their records, and negative answers for the negative TTL of the zone's SOA, as in RFC 2308.
+func (ri *ResolverIngester) ttlOf(response *dns.Msg, rrs []dns.RR) (ttl time.Duration) {
+	switch {
+	case len(rrs) > 0:
+		ttl = hashy.SecondsToDuration(rrs[0].Header().TTL)
+		for _, rr := range rrs[1:] {
+			ttl = min(ttl, hashy.SecondsToDuration(rr.Header().TTL))
+		}
+
+	default:
+		for _, rr := range response.Ns {
+			if soa, ok := rr.(*dns.SOA); ok {
+				ttl = hashy.SecondsToDuration(min(soa.Hdr.TTL, soa.Minttl))
+			}
+		}
+	}
+
+	return max(ttl, ri.minTTL)
+}
+
+// resolve returns the records of a type for a name, either from the cache or from a resolver.
+// Records are returned with the queried name as their owner, even if they were reached through a
+// CNAME, and sorted so that the order of an answer does not matter.
+func (ri *ResolverIngester) resolve(ctx context.Context, now time.Time, visited map[rrsetKey]bool, name string, qtype uint16) (rrs []dns.RR, err error) {
+	key := rrsetKey{name: dnsutil.Canonical(name), rrType: qtype}
+	visited[key] = true
+	if cached, exists := ri.cache[key]; exists && now.Before(cached.expires) {
+		rrs = cached.rrs
+		return
+	}
+
+	_, span := ri.tracer.Start(ctx, "ingest.resolve", trace.WithAttributes(
+		attribute.String("dns.question.name", key.name),
+		attribute.String("dns.question.type", dns.TypeToString[qtype]),
+	))
+
+	defer func() {
+		span.SetAttributes(attribute.Int("hashy.rrs", len(rrs)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
+	var response *dns.Msg
+	if response, err = ri.exchange(ctx, key.name, qtype); err != nil {
+		return
+	}
+
+	for _, rr := range response.Answer {
+		if dns.RRToType(rr) == qtype {
+			rr = rr.Clone()
+			rr.Header().Name = key.name
+			rrs = append(rrs, rr)
+		}
+	}
+
+	slices.SortFunc(rrs, func(a, b dns.RR) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	ttl := ri.ttlOf(response, rrs)
+	ri.logger.Debug("resolved", zap.String("name", key.name), zap.String("type", dns.TypeToString[qtype]), zap.Int("rrs", len(rrs)), zap.Duration("ttl", ttl))
+	ri.cache[key] = resolvedRRset{
+		rrs:     rrs,
+		expires: now.Add(ttl),
+	}
+
+	return
+}
+
+// writeChecksum writes the parts of an RR that matter to groups, which excludes its TTL, since
+// caching resolvers count TTLs down.
+func writeChecksum(w io.Writer, rr dns.RR) error {
+	rr = rr.Clone()
+	rr.Header().TTL = 0
+	_, err := io.WriteString(w, rr.String())
+	return err
+}
+
+// discover follows the discovery domain's group definitions to their services, and
+// each service to its targets, appending every record found to the source.
+func (ri *ResolverIngester) discover(ctx context.Context, now time.Time, visited map[rrsetKey]bool, checksummer medley.Hash[uint32], src *SourceRRs) error {
+	validator := RRCollector{
+		discoveryDomain: ri.discoveryDomain,
+	}
+
+	add := func(rrs []dns.RR) error {
+		for _, rr := range rrs {
+			if err := writeChecksum(checksummer, rr); err != nil {
+				return err
+			}
+
+			if err := validator.addRR(rr); err != nil {
+				return err
+			}
+
+			src.RRs = append(src.RRs, rr)
+		}
+
+		return nil
+	}
+
+	definitions, err := ri.resolve(ctx, now, visited, ri.discoveryDomain, dns.TypeTXT)
+	if err == nil {
+		err = add(definitions)
+	}
+
+	var services []string
+	for _, rr := range definitions {
+		for _, txt := range rr.(*dns.TXT).Txt {
+			// the definition is known to be valid, since the validator accepted it
+			gdef, _ := ParseGroupDefinition(txt)
+			for _, s := range gdef.Services {
+				if s = dnsutil.Canonical(s); !slices.Contains(services, s) {
+					services = append(services, s)
+				}
+			}
+		}
+	}
+
+	var targets []string
+	for i := 0; err == nil && i < len(services); i++ {
+		var srvs []dns.RR
+		if srvs, err = ri.resolve(ctx, now, visited, services[i], dns.TypeSRV); err == nil {
+			err = add(srvs)
+		}
+
+		for _, rr := range srvs {
+			if t := dnsutil.Canonical(rr.(*dns.SRV).Target); !slices.Contains(targets, t) {
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	for i := 0; err == nil && i < len(targets); i++ {
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA, dns.TypeTXT} {
+			var rrs []dns.RR
+			if rrs, err = ri.resolve(ctx, now, visited, targets[i], qtype); err == nil {
+				err = add(rrs)
+			}
+
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	return err
+}
+
+// read resolves the records that make up groups, sending queries only for records whose
+// TTLs have expired. The ingest lock must be held.
+func (ri *ResolverIngester) read(ctx context.Context, checksummer medley.Hash[uint32]) (src SourceRRs, err error) {
+	now := time.Now()
+	visited := make(map[rrsetKey]bool)
+	if err = ri.discover(ctx, now, visited, checksummer, &src); err != nil {
+		ri.logger.Error("resolution failed", zap.Error(err))
+		return
+	}
+
+	// forget anything that is no longer referenced, and schedule the next ingest
+	// for when the first remaining record expires
+	var next time.Time
+	for key, cached := range ri.cache {
+		switch {
+		case !visited[key]:
+			delete(ri.cache, key)
+
+		case next.IsZero() || cached.expires.Before(next):
+			next = cached.expires
+		}
+	}
+
+	ri.nextIngest.Store(&next)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("hashy.rrsets", len(visited)))
+	return
+}
+
+// ReadRRs resolves the records that make up groups, sending queries only for records whose
+// TTLs have expired. Each record, without its TTL, is written to the checksummer.
+func (ri *ResolverIngester) ReadRRs(ctx context.Context, checksummer medley.Hash[uint32]) (SourceRRs, error) {
+	defer ri.ingestLock.Unlock()
+	ri.ingestLock.Lock()
+
+	return ri.read(ctx, checksummer)
+}
+
+// Ingest resolves the records that make up groups, sending queries only for records whose TTLs
+// have expired. An IngestEvent is only dispatched if either (a) this is the first Ingest, or (b) any
+// resolved record or the overlay changed.
+func (ri *ResolverIngester) Ingest(ctx context.Context) {
+	ri.ingest(ctx, ri.read)
+}
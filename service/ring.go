@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/xmidt-org/medley"
+	"github.com/xmidt-org/medley/consistent"
+)
+
+// DefaultVNodes is the number of virtual nodes each member has on a ring when none is configured.
+const DefaultVNodes = 128
+
+// vnode is a single position of a member on a ring.
+type vnode struct {
+	token  uint64
+	member *member
+}
+
+// ringPlacement is a consistent hash ring for a single group. The nearest endpoint comes from
+// a medley ring, built exactly as earlier releases of hashy built it, so the endpoint each object
+// is assigned never changes across upgrades. Adding or removing an endpoint only moves the objects
+// between that endpoint and its neighbors.
+//
+// A medley ring only finds the nearest endpoint, so successors come from a second ring of hashy's
+// own, whose vnode tokens likewise depend only on each member. The successors are found by walking
+// clockwise from the object's position on that ring and skipping endpoints already chosen.
+type ringPlacement struct {
+	nearest   *consistent.Ring[*member]
+	vnodes    []vnode
+	endpoints int
+}
+
+// newRingPlacement builds the rings for a group's members. The builder creates the medley ring,
+// while vnodesPerMember is the number of vnodes each member has on the successor ring.
+func newRingPlacement(builder consistent.Builder[string, *member], vnodesPerMember int, members []*member) *ringPlacement {
+	if vnodesPerMember < 1 {
+		vnodesPerMember = DefaultVNodes
+	}
+
+	rp := &ringPlacement{
+		nearest: builder.Build(
+			len(members),
+			medley.Objectify(
+				func(m *member) string {
+					return m.id
+				},
+				slices.Values(members),
+			),
+		),
+		vnodes:    make([]vnode, 0, len(members)*vnodesPerMember),
+		endpoints: distinctEndpoints(members),
+	}
+
+	for _, m := range members {
+		for i := range vnodesPerMember {
+			rp.vnodes = append(rp.vnodes, vnode{
+				token:  mix64(m.hash + uint64(i)*0x9e3779b97f4a7c15),
+				member: m,
+			})
+		}
+	}
+
+	slices.SortFunc(rp.vnodes, func(v1, v2 vnode) int {
+		// ties are vanishingly rare, but must still be ordered the same way every time
+		return cmp.Or(
+			cmp.Compare(v1.token, v2.token),
+			cmp.Compare(v1.member.id, v2.member.id),
+		)
+	})
+
+	return rp
+}
+
+// Len returns the number of endpoints in this ring.
+func (rp *ringPlacement) Len() int {
+	return rp.endpoints
+}
+
+// owner returns the member of the medley ring nearest to an object.
+func (rp *ringPlacement) owner(key objectKey) *member {
+	if key.isString {
+		return rp.nearest.NearestString(key.str)
+	}
+
+	return rp.nearest.Nearest(key.bytes)
+}
+
+// search returns the index of the vnode an object matches on the successor ring, which is
+// the first vnode at or after the object's hash, wrapping around the ring.
+func (rp *ringPlacement) search(key objectKey) int {
+	h := key.hash()
+	i := sort.Search(len(rp.vnodes), func(i int) bool {
+		return rp.vnodes[i].token >= h
+	})
+
+	if i == len(rp.vnodes) {
+		i = 0
+	}
+
+	return i
+}
+
+// appendNearest appends up to n distinct endpoints to dst. The first endpoint appended is
+// always the nearest endpoint on the medley ring, followed by its successors in ring order.
+func (rp *ringPlacement) appendNearest(dst LocatedEndpoints, n int, key objectKey) LocatedEndpoints {
+	if rp.Len() == 0 || n < 1 {
+		return dst
+	}
+
+	start := len(dst)
+	dst = append(dst, LocatedEndpoint{
+		Endpoint: rp.owner(key).endpoint,
+	})
+
+	n = min(n, rp.Len())
+	for i, step := rp.search(key), 0; step < len(rp.vnodes) && len(dst)-start < n; step++ {
+		if e := rp.vnodes[i].member.endpoint; !dst[start:].contains(e) {
+			dst = append(dst, LocatedEndpoint{
+				Endpoint: e,
+				Rank:     len(dst) - start,
+			})
+		}
+
+		if i++; i == len(rp.vnodes) {
+			i = 0
+		}
+	}
+
+	return dst
+}
+
+// token returns the member the object was assigned, followed by the vnode that its
+// successors were walked from.
+func (rp *ringPlacement) token(key objectKey) string {
+	if rp.Len() == 0 {
+		return ""
+	}
+
+	i := rp.search(key)
+	return "member " + rp.owner(key).id + " vnode " + rp.vnodes[i].member.id + " " + strconv.FormatUint(rp.vnodes[i].token, 16)
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/xmidt-org/medley"
+	"github.com/xmidt-org/medley/consistent"
+)
+
+// newSampleGroups ingests the groups of the sample zone files.
+func newSampleGroups(t *testing.T) *Groups {
+	t.Helper()
+	var last lastIngest
+	fi, err := NewFileIngester(
+		WithGlobs("../sample/*.zone"),
+		WithIngestListeners(&last),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi.Ingest(context.Background())
+	if last.event.Err != nil || last.event.Groups == nil {
+		t.Fatalf("the sample groups were not ingested: %v", last.event.Err)
+	}
+
+	return last.event.Groups
+}
+
+// newBaselineRing builds a group's ring exactly as hashy did before placement algorithms,
+// as a medley ring of the group's endpoints keyed by original name.
+func newBaselineRing(vnodes int, g *Group) *consistent.Ring[*Endpoint] {
+	var builder consistent.Builder[string, *Endpoint]
+	builder.VNodes(vnodes)
+	return builder.Build(
+		g.Len(),
+		medley.Objectify(
+			func(e *Endpoint) string {
+				return e.OriginalName()
+			},
+			g.Endpoints(),
+		),
+	)
+}
+
+func TestRingNearestMatchesBaseline(t *testing.T) {
+	gps := newSampleGroups(t)
+	for _, vnodes := range []int{0, 50, DefaultVNodes} {
+		for _, replicas := range []int{1, 3} {
+			t.Run(fmt.Sprintf("vnodes=%d,replicas=%d", vnodes, replicas), func(t *testing.T) {
+				loc, err := NewLocator(WithVNodes(vnodes), WithReplicas(replicas))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				loc.Update(gps)
+				for g := range gps.All() {
+					baseline := newBaselineRing(vnodes, g)
+					for i := range 1000 {
+						object := "mac:" + strconv.Itoa(i)
+						expected := baseline.NearestString(object)
+						located := loc.FindString(object, g.Name())
+						if len(located) != min(replicas, g.Len()) {
+							t.Fatalf("expected %d endpoints, got %d", min(replicas, g.Len()), len(located))
+						}
+
+						if located[0].Endpoint != expected {
+							t.Fatalf("%s moved from %s to %s in group %s", object, expected.OriginalName(), located[0].OriginalName(), g.Name())
+						}
+
+						for rank, le := range located {
+							if le.Rank != rank {
+								t.Fatalf("expected rank %d, got %d", rank, le.Rank)
+							}
+
+							for _, other := range located[:rank] {
+								if other.Endpoint == le.Endpoint {
+									t.Fatalf("%s was located more than once", le.OriginalName())
+								}
+							}
+						}
+					}
+				}
+			})
+		}
+	}
+}
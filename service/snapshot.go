@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/hashy"
+	"go.uber.org/zap"
+)
+
+// SnapshotVersion is the version of the snapshot format written by a SnapshotStore.
+// Snapshots with any other version are not loaded.
+const SnapshotVersion = 1
+
+// snapshot is the on-disk representation of a Groups.
+type snapshot struct {
+	Version int             `json:"version"`
+	Time    time.Time       `json:"time"`
+	Groups  []snapshotGroup `json:"groups"`
+}
+
+type snapshotGroup struct {
+	Name       string             `json:"name"`
+	Services   []string           `json:"services,omitempty"`
+	Attributes Attributes         `json:"attributes,omitempty"`
+	Endpoints  []snapshotEndpoint `json:"endpoints,omitempty"`
+	Unresolved []string           `json:"unresolved,omitempty"`
+}
+
+type snapshotEndpoint struct {
+	Name       string       `json:"name"`
+	Priority   uint16       `json:"priority"`
+	Weight     uint16       `json:"weight"`
+	Port       uint16       `json:"port"`
+	Attributes Attributes   `json:"attributes,omitempty"`
+	IP4        []netip.Addr `json:"ip4,omitempty"`
+	IP6        []netip.Addr `json:"ip6,omitempty"`
+}
+
+// newSnapshot creates the on-disk representation of a Groups.
+func newSnapshot(gps *Groups) (s snapshot) {
+	s.Version = SnapshotVersion
+	s.Time = time.Now().UTC()
+	s.Groups = make([]snapshotGroup, 0, gps.Len())
+	for g := range gps.All() {
+		sg := snapshotGroup{
+			Name:       g.name,
+			Services:   g.services,
+			Attributes: g.attributes,
+			Endpoints:  make([]snapshotEndpoint, 0, len(g.endpoints)),
+			Unresolved: g.unresolved,
+		}
+
+		for e := range g.Endpoints() {
+			sg.Endpoints = append(sg.Endpoints, snapshotEndpoint{
+				Name:       e.originalName,
+				Priority:   e.priority,
+				Weight:     e.weight,
+				Port:       e.port,
+				Attributes: e.attributes,
+				IP4:        e.ip4,
+				IP6:        e.ip6,
+			})
+		}
+
+		s.Groups = append(s.Groups, sg)
+	}
+
+	return
+}
+
+// groups rebuilds the Groups this snapshot was created from.
+func (s snapshot) groups() (*Groups, error) {
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version: %d", s.Version)
+	}
+
+	gps := &Groups{
+		byName: make(map[string]int, len(s.Groups)),
+		all:    make([]Group, 0, len(s.Groups)),
+	}
+
+	for _, sg := range s.Groups {
+		if _, exists := gps.byName[sg.Name]; exists {
+			return nil, fmt.Errorf("duplicate group in snapshot: %s", sg.Name)
+		}
+
+		g := Group{
+			name:       sg.Name,
+			services:   sg.Services,
+			attributes: sg.Attributes,
+			endpoints:  make([]Endpoint, 0, len(sg.Endpoints)),
+			unresolved: sg.Unresolved,
+		}
+
+		for _, se := range sg.Endpoints {
+			g.endpoints = append(g.endpoints, Endpoint{
+				originalName: se.Name,
+				priority:     se.Priority,
+				weight:       se.Weight,
+				port:         se.Port,
+				attributes:   se.Attributes,
+				ip4:          hashy.Values[netip.Addr](se.IP4),
+				ip6:          hashy.Values[netip.Addr](se.IP6),
+			})
+		}
+
+		gps.byName[sg.Name] = len(gps.all)
+		gps.all = append(gps.all, g)
+	}
+
+	return gps, nil
+}
+
+type SnapshotStoreOption interface {
+	applyToSnapshotStore(*SnapshotStore) error
+}
+
+type snapshotStoreOptionFunc func(*SnapshotStore) error
+
+func (f snapshotStoreOptionFunc) applyToSnapshotStore(ss *SnapshotStore) error { return f(ss) }
+
+func WithSnapshotLogger(base *zap.Logger) SnapshotStoreOption {
+	return snapshotStoreOptionFunc(func(ss *SnapshotStore) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		ss.logger = base.Named("snapshot")
+		return nil
+	})
+}
+
+// WithSnapshotPath sets the file that holds the snapshot. This option is required.
+func WithSnapshotPath(path string) SnapshotStoreOption {
+	return snapshotStoreOptionFunc(func(ss *SnapshotStore) error {
+		ss.path = os.ExpandEnv(path)
+		return nil
+	})
+}
+
+// SnapshotStore saves the last known good Groups to a file, so that a restart never makes
+// hashy forget its topology. As an IngestListener, a SnapshotStore saves the groups from each
+// successful ingest, which have already passed any validators. Each save atomically replaces
+// the file.
+type SnapshotStore struct {
+	logger *zap.Logger
+	path   string
+
+	saveLock sync.Mutex
+}
+
+// NewSnapshotStore creates a SnapshotStore from a set of options.
+func NewSnapshotStore(opts ...SnapshotStoreOption) (*SnapshotStore, error) {
+	ss := new(SnapshotStore)
+	for _, o := range opts {
+		if err := o.applyToSnapshotStore(ss); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ss.path) == 0 {
+		return nil, errors.New("a snapshot path is required")
+	}
+
+	if ss.logger == nil {
+		ss.logger = zap.NewNop()
+	}
+
+	return ss, nil
+}
+
+// Save atomically replaces the snapshot with the given groups.
+func (ss *SnapshotStore) Save(gps *Groups) error {
+	data, err := json.Marshal(newSnapshot(gps))
+	if err != nil {
+		return err
+	}
+
+	defer ss.saveLock.Unlock()
+	ss.saveLock.Lock()
+
+	temp, err := os.CreateTemp(filepath.Dir(ss.path), filepath.Base(ss.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(temp.Name()) // a no-op once renamed
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), ss.path)
+	}
+
+	return err
+}
+
+// Load reads the groups from the snapshot. If no snapshot has been saved,
+// this method returns nil groups and no error.
+func (ss *SnapshotStore) Load() (*Groups, error) {
+	data, err := os.ReadFile(ss.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", ss.path, err)
+	}
+
+	gps, err := s.groups()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ss.path, err)
+	}
+
+	ss.logger.Info("snapshot loaded", zap.String("path", ss.path), zap.Time("time", s.Time), zap.Int("groups", gps.Len()))
+	return gps, nil
+}
+
+// Seed dispatches the groups from the snapshot, if any, to the given listeners and returns them.
+// This is done before the first ingest, so that hashy serves its last known good groups even if
+// that ingest fails. A snapshot that cannot be loaded is logged and otherwise ignored.
+func (ss *SnapshotStore) Seed(listeners ...IngestListener) (gps *Groups) {
+	gps, err := ss.Load()
+	switch {
+	case err != nil:
+		ss.logger.Error("unable to load snapshot", zap.Error(err))
+		gps = nil
+
+	case gps != nil:
+		event := IngestEvent{Groups: gps}
+		for _, l := range listeners {
+			if l != IngestListener(ss) {
+				l.OnIngest(event)
+			}
+		}
+	}
+
+	return
+}
+
+// OnIngest saves the groups from a successful ingest.
+func (ss *SnapshotStore) OnIngest(event IngestEvent) {
+	if event.Err != nil || event.Groups == nil {
+		return
+	}
+
+	if err := ss.Save(event.Groups); err != nil {
+		ss.logger.Error("unable to save snapshot", zap.String("path", ss.path), zap.Error(err))
+	}
+}
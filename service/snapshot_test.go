@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingListener is an IngestListener that counts its events.
+type countingListener int
+
+func (cl *countingListener) OnIngest(IngestEvent) { *cl++ }
+
+// snapshotJSON returns the JSON snapshot of a Groups, without a time.
+func snapshotJSON(t *testing.T, gps *Groups) string {
+	t.Helper()
+	s := newSnapshot(gps)
+	s.Time = time.Time{}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestSnapshotStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	ss, err := NewSnapshotStore(WithSnapshotPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listener countingListener
+	if gps := ss.Seed(&listener); gps != nil || listener != 0 {
+		t.Fatal("a missing snapshot must not seed any groups")
+	}
+
+	gps := newSampleGroups(t)
+	ss.OnIngest(IngestEvent{Groups: gps})
+	ss.OnIngest(IngestEvent{Groups: newTestGroups(t), Err: ErrIngestRejected})
+
+	seeded := ss.Seed(&listener)
+	if seeded == nil || listener != 1 {
+		t.Fatal("expected the saved groups to be seeded")
+	}
+
+	if expected, actual := snapshotJSON(t, gps), snapshotJSON(t, seeded); expected != actual {
+		t.Errorf("expected the snapshot %s, got %s", expected, actual)
+	}
+}
+
+func TestSnapshotStoreFallback(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "corrupt", data: `{"version": 1, "groups": [`},
+		{name: "stale", data: `{"version": 0, "groups": [{"name": "test"}]}`},
+		{name: "newer", data: `{"version": 2, "groups": [{"name": "test"}]}`},
+		{name: "duplicate group", data: `{"version": 1, "groups": [{"name": "test"}, {"name": "test"}]}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.json")
+			if err := os.WriteFile(path, []byte(testCase.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			ss, err := NewSnapshotStore(WithSnapshotPath(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ss.Load(); err == nil {
+				t.Error("expected an error")
+			}
+
+			var listener countingListener
+			if gps := ss.Seed(&listener); gps != nil || listener != 0 {
+				t.Error("a snapshot that cannot be loaded must not seed any groups")
+			}
+
+			// the next successful ingest replaces the snapshot
+			ss.OnIngest(IngestEvent{Groups: newSampleGroups(t)})
+			if gps, err := ss.Load(); err != nil || gps == nil {
+				t.Errorf("expected the snapshot to be replaced: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/medley"
+)
+
+// StaticSourceName is the name of the source that holds the static groups from configuration.
+const StaticSourceName = "static"
+
+// NewStaticDocument creates the GroupsDocument for groups declared in configuration.
+func NewStaticDocument(static []config.StaticGroup) (doc GroupsDocument, err error) {
+	doc.Groups = make([]DocumentGroup, 0, len(static))
+	for _, sg := range static {
+		g := DocumentGroup{
+			Name:       sg.Name,
+			Attributes: Attributes(sg.Attributes),
+		}
+
+		for _, ss := range sg.Services {
+			s := DocumentService{
+				Name: ss.Name,
+			}
+
+			for _, se := range ss.Endpoints {
+				e := DocumentEndpoint{
+					Name:       se.Name,
+					Port:       se.Port,
+					Weight:     se.Weight,
+					Priority:   se.Priority,
+					Attributes: Attributes(se.Attributes),
+				}
+
+				for _, a := range se.Addresses {
+					var addr netip.Addr
+					if addr, err = netip.ParseAddr(a); err != nil {
+						err = fmt.Errorf("static group %s: endpoint %s: %w", sg.Name, se.Name, err)
+						return
+					}
+
+					e.Addresses = append(e.Addresses, addr)
+				}
+
+				s.Endpoints = append(s.Endpoints, e)
+			}
+
+			g.Services = append(g.Services, s)
+		}
+
+		doc.Groups = append(doc.Groups, g)
+	}
+
+	return
+}
+
+// StaticSource is an RRSource for the static groups declared in configuration. Its records
+// are produced once, when it is created, and never change.
+type StaticSource struct {
+	rrs []dns.RR
+}
+
+// NewStaticSource creates a StaticSource with the records of the given static groups. Any
+// problem with the groups, such as an invalid group name, is returned as an error.
+func NewStaticSource(discoveryDomain string, ttl time.Duration, static []config.StaticGroup) (*StaticSource, error) {
+	if len(discoveryDomain) == 0 {
+		discoveryDomain = DefaultDiscoveryDomain
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultFileIngesterTTL
+	}
+
+	doc, err := NewStaticDocument(static)
+	if err != nil {
+		return nil, err
+	}
+
+	rrs, err := doc.RRs(discoveryDomain, hashy.DurationToSeconds(ttl))
+	if err != nil {
+		return nil, fmt.Errorf("static groups: %w", err)
+	}
+
+	validator := RRCollector{
+		discoveryDomain: dnsutil.Fqdn(discoveryDomain),
+	}
+
+	for _, rr := range rrs {
+		if err := validator.addRR(rr); err != nil {
+			return nil, fmt.Errorf("static groups: %w", err)
+		}
+	}
+
+	return &StaticSource{
+		rrs: rrs,
+	}, nil
+}
+
+// ReadRRs returns the records of the static groups. Since these records never change,
+// nothing is written to the checksummer.
+func (ss *StaticSource) ReadRRs(context.Context, medley.Hash[uint32]) (SourceRRs, error) {
+	return SourceRRs{
+		RRs: slices.Clone(ss.rrs),
+	}, nil
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xmidt-org/hashy/config"
+	"go.uber.org/zap"
+)
+
+const testStaticZone = `$ORIGIN example.org.
+_hashy.discover. 60 IN TXT "first _zone._tcp.example.org. algorithm=jump"
+_zone._tcp 60 IN SRV 0 1 8080 e1.example.org.
+e1 60 IN A 192.0.2.1
+`
+
+func TestStaticGroupsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "test.zone"), []byte(testStaticZone), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gcfg := config.Groups{
+		ZoneFiles: []string{filepath.Join(dir, "*.zone")},
+		Static: []config.StaticGroup{
+			{
+				Name:       "first",
+				Attributes: map[string]string{AlgorithmAttribute: string(AlgorithmRendezvous), "region": "east"},
+				Services: []config.StaticService{
+					{
+						Name: "_static._tcp.example.org.",
+						Endpoints: []config.StaticEndpoint{
+							{Name: "e1.example.org.", Addresses: []string{"198.51.100.1"}, Port: 8080},
+							{Name: "e2.example.org.", Addresses: []string{"192.0.2.2"}, Port: 8080},
+						},
+					},
+				},
+			},
+			{
+				Name:       "second",
+				Attributes: map[string]string{AlgorithmAttribute: string(AlgorithmMaglev)},
+				Services: []config.StaticService{
+					{
+						Name: "_second._tcp.example.org.",
+						Endpoints: []config.StaticEndpoint{
+							{Name: "e3.example.org.", Addresses: []string{"192.0.2.3"}, Port: 8080},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var last lastIngest
+	ci, err := newCompositeIngester(zap.NewNop(), gcfg, nil, nil, nil, []IngestListener{&last}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ci.Ingest(context.Background())
+	if last.event.Err != nil {
+		t.Fatal(last.event.Err)
+	}
+
+	first := last.event.Groups.Get("first")
+	if first == nil {
+		t.Fatal("expected the first group")
+	}
+
+	// the zone files take precedence over the static groups, for both RRsets and attributes
+	for key, expected := range map[string]string{AlgorithmAttribute: string(AlgorithmJump), "region": "east"} {
+		if value, _ := first.Attributes().Get(key); value != expected {
+			t.Errorf("expected %s=%s, got %s", key, expected, value)
+		}
+	}
+
+	addrs := groupAddrs(first)
+	if !slices.Equal(addrs["e1.example.org."], []string{"192.0.2.1"}) || !slices.Equal(addrs["e2.example.org."], []string{"192.0.2.2"}) {
+		t.Errorf("unexpected endpoints: %v", addrs)
+	}
+
+	if second := last.event.Groups.Get("second"); second == nil || second.Len() != 1 {
+		t.Error("expected the second group, which only the static groups declare")
+	}
+}
+
+func TestNewStaticSource(t *testing.T) {
+	testCases := []struct {
+		name   string
+		static []config.StaticGroup
+		err    bool
+	}{
+		{
+			name: "valid",
+			static: []config.StaticGroup{
+				{Name: "test", Services: []config.StaticService{{Name: "_test._tcp.example.org."}}},
+			},
+		},
+		{
+			name: "invalid address",
+			static: []config.StaticGroup{
+				{
+					Name: "test",
+					Services: []config.StaticService{
+						{
+							Name:      "_test._tcp.example.org.",
+							Endpoints: []config.StaticEndpoint{{Name: "e1.example.org.", Addresses: []string{"not an address"}}},
+						},
+					},
+				},
+			},
+			err: true,
+		},
+		{
+			name: "invalid attribute",
+			static: []config.StaticGroup{
+				{
+					Name: "test",
+					Services: []config.StaticService{
+						{
+							Name:      "_test._tcp.example.org.",
+							Endpoints: []config.StaticEndpoint{{Name: "e1.example.org.", Attributes: map[string]string{WeightAttribute: "heavy"}}},
+						},
+					},
+				},
+			},
+			err: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewStaticSource("", 0, testCase.static)
+			if testCase.err && err == nil {
+				t.Error("expected an error")
+			} else if !testCase.err && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
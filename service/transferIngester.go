@@ -0,0 +1,475 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnsutil"
+	"github.com/xmidt-org/hashy/config"
+	"github.com/xmidt-org/medley"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultTransferPort is the port used for a primary server that has no port.
+	DefaultTransferPort = "53"
+
+	// DefaultTransferAlgorithm is the TSIG algorithm used when a key has no algorithm.
+	DefaultTransferAlgorithm = dns.HmacSHA256
+
+	// DefaultTransferTimeout is the default time allowed for each zone transfer.
+	DefaultTransferTimeout = 30 * time.Second
+)
+
+// errFullTransferRequired indicates that a primary answered an IXFR with only a newer SOA,
+// which means the zone must be transferred in full.
+var errFullTransferRequired = errors.New("a full zone transfer is required")
+
+// transferZone is a zone pulled from a primary server, along with the
+// state of the most recent successful transfer.
+type transferZone struct {
+	zone      string
+	primary   string
+	key       string
+	algorithm string
+	signer    dns.TSIGSigner
+	timeout   time.Duration
+
+	// soa is the SOA from the most recent transfer, which is nil until the first AXFR succeeds
+	soa *dns.SOA
+
+	// rrs are the zone's RRs, excluding the SOA
+	rrs []dns.RR
+}
+
+// newTransferZone validates a transfer's configuration.
+func newTransferZone(cfg config.Transfer) (*transferZone, error) {
+	if len(cfg.Zone) == 0 {
+		return nil, errors.New("a zone transfer requires a zone")
+	}
+
+	if len(cfg.Primary) == 0 {
+		return nil, fmt.Errorf("no primary server for zone transfer: %s", cfg.Zone)
+	}
+
+	tz := &transferZone{
+		zone:    dnsutil.Canonical(cfg.Zone),
+		primary: cfg.Primary,
+		timeout: cfg.Timeout,
+	}
+
+	if _, _, err := net.SplitHostPort(tz.primary); err != nil {
+		tz.primary = net.JoinHostPort(tz.primary, DefaultTransferPort)
+	}
+
+	if tz.timeout <= 0 {
+		tz.timeout = DefaultTransferTimeout
+	}
+
+	if len(cfg.Key) > 0 {
+		secret, err := base64.StdEncoding.DecodeString(cfg.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret for TSIG key %s: %w", cfg.Key, err)
+		}
+
+		tz.key = dnsutil.Canonical(cfg.Key)
+		tz.algorithm = DefaultTransferAlgorithm
+		if len(cfg.Algorithm) > 0 {
+			tz.algorithm = dnsutil.Canonical(cfg.Algorithm)
+		}
+
+		tz.signer = dns.HmacTSIG{Secret: secret}
+	}
+
+	return tz, nil
+}
+
+// request creates the transfer request for this zone. The first request is always
+// an AXFR. Subsequent requests are IXFRs from the serial of the most recent transfer.
+func (tz *transferZone) request() (m *dns.Msg) {
+	if tz.soa == nil {
+		m = dns.NewMsg(tz.zone, dns.TypeAXFR)
+	} else {
+		m = dns.NewMsg(tz.zone, dns.TypeIXFR)
+		m.Ns = append(m.Ns, tz.soa)
+	}
+
+	if tz.signer != nil {
+		m.Pseudo = append(m.Pseudo, dns.NewTSIG(tz.key, tz.algorithm, 0))
+	}
+
+	return
+}
+
+// transfer performs a single AXFR or IXFR, returning all the RRs the primary sent.
+func (tz *transferZone) transfer(ctx context.Context, m *dns.Msg) (rrs []dns.RR, err error) {
+	ctx, cancel := context.WithTimeout(ctx, tz.timeout)
+	defer cancel()
+
+	client := dns.NewClient()
+	client.ReadTimeout = tz.timeout
+	client.WriteTimeout = tz.timeout
+	if tz.signer != nil {
+		client.Transfer = &dns.Transfer{TSIGSigner: tz.signer}
+	}
+
+	var envelopes <-chan *dns.Envelope
+	if envelopes, err = client.TransferIn(ctx, m, "tcp", tz.primary); err != nil {
+		return
+	}
+
+	for e := range envelopes {
+		if e.Error != nil && err == nil {
+			err = e.Error
+		}
+
+		rrs = append(rrs, e.Answer...)
+	}
+
+	if err == nil {
+		// the channel is closed when the transfer is cut short, so make sure
+		// the transfer ended with a SOA
+		if len(rrs) == 0 {
+			err = fmt.Errorf("empty transfer for zone: %s", tz.zone)
+		} else if _, ok := rrs[len(rrs)-1].(*dns.SOA); !ok {
+			err = fmt.Errorf("incomplete transfer for zone: %s", tz.zone)
+		}
+	}
+
+	return
+}
+
+// apply updates this zone's state from the RRs of a transfer. The RRs are either a full zone,
+// as sent for an AXFR, or a sequence of differences as defined by RFC 1995. A single SOA with the
+// current serial means the zone is already up to date, which is an error when there is no current zone.
+// This method returns true if the zone changed.
+func (tz *transferZone) apply(rrs []dns.RR) (changed bool, err error) {
+	soa, _ := rrs[0].(*dns.SOA)
+	switch {
+	case soa == nil:
+		err = fmt.Errorf("transfer for zone %s does not start with a SOA", tz.zone)
+
+	case len(rrs) == 1:
+		// the primary either has nothing newer than what we have, or it cannot send differences
+		if tz.soa == nil {
+			err = fmt.Errorf("incomplete transfer for zone: %s", tz.zone)
+		} else if tz.soa.Serial != soa.Serial {
+			err = errFullTransferRequired
+		}
+
+	case tz.soa != nil && len(rrs) > 2 && dns.RRToType(rrs[1]) == dns.TypeSOA:
+		var updated []dns.RR
+		if updated, err = applyDifferences(tz.rrs, rrs[1:len(rrs)-1]); err == nil {
+			tz.soa, tz.rrs, changed = soa, updated, true
+		}
+
+	default:
+		// a full zone, either from an AXFR or from an IXFR that the primary could not answer incrementally
+		tz.soa, changed = soa, true
+		tz.rrs = slices.Clone(rrs[1 : len(rrs)-1])
+	}
+
+	return
+}
+
+// applyDifferences applies RFC 1995 difference sequences to a copy of a zone's RRs. Each sequence
+// is the old SOA followed by the deleted RRs, then the new SOA followed by the added RRs.
+func applyDifferences(current, differences []dns.RR) (updated []dns.RR, err error) {
+	updated = slices.Clone(current)
+	adding := true
+	for _, rr := range differences {
+		switch {
+		case dns.RRToType(rr) == dns.TypeSOA:
+			// each SOA alternates between deleting and adding
+			adding = !adding
+
+		case adding:
+			updated = append(updated, rr)
+
+		default:
+			i := slices.IndexFunc(updated, func(candidate dns.RR) bool {
+				return dns.Equal(candidate, rr)
+			})
+
+			if i < 0 {
+				err = fmt.Errorf("incremental transfer deletes a missing RR: %s", rr)
+				return
+			}
+
+			updated = slices.Delete(updated, i, i+1)
+		}
+	}
+
+	if !adding {
+		err = errors.New("incremental transfer ends with a deletion sequence")
+	}
+
+	return
+}
+
+type TransferIngesterOption interface {
+	applyToTransferIngester(*TransferIngester) error
+}
+
+type transferIngesterOptionFunc func(*TransferIngester) error
+
+func (f transferIngesterOptionFunc) applyToTransferIngester(ti *TransferIngester) error { return f(ti) }
+
+func WithTransferLogger(base *zap.Logger) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		if base == nil {
+			base = zap.NewNop()
+		}
+
+		ti.logger = base.Named("transferIngester")
+		return nil
+	})
+}
+
+// WithTransfers adds zones to pull from primary servers.
+func WithTransfers(more ...config.Transfer) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		ti.zones = slices.Grow(ti.zones, len(more))
+		for _, cfg := range more {
+			tz, err := newTransferZone(cfg)
+			if err != nil {
+				return err
+			}
+
+			ti.zones = append(ti.zones, tz)
+		}
+
+		return nil
+	})
+}
+
+func WithTransferDiscoveryDomain(domain string) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		if len(domain) > 0 {
+			ti.discoveryDomain = dnsutil.Fqdn(domain)
+		} else {
+			ti.discoveryDomain = ""
+		}
+
+		return nil
+	})
+}
+
+func WithTransferListeners(more ...IngestListener) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		ti.listeners = slices.Grow(ti.listeners, len(more))
+		ti.listeners = append(ti.listeners, more...)
+		return nil
+	})
+}
+
+// WithTransferValidators adds validators that the groups from each ingest must pass before they
+// are dispatched. Groups that fail are rejected, and an error event is dispatched instead.
+func WithTransferValidators(more ...IngestValidator) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		ti.gate.validators = append(ti.gate.validators, more...)
+		return nil
+	})
+}
+
+// WithTransferMetrics sets the prometheus metrics a TransferIngester records. By default, no metrics are recorded.
+func WithTransferMetrics(m *IngestMetrics) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		ti.metrics = m
+		return nil
+	})
+}
+
+// WithTransferTracerProvider sets the OpenTelemetry provider a TransferIngester uses to create
+// a span for each ingest, with a child span for each zone transfer. By default, no spans are recorded.
+func WithTransferTracerProvider(tp trace.TracerProvider) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		if tp != nil {
+			ti.tracer = tp.Tracer(TracerName)
+		}
+
+		return nil
+	})
+}
+
+// WithTransferOverlay sets the Overlay of dynamic changes merged with the transferred zones.
+func WithTransferOverlay(o *Overlay) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) error {
+		ti.overlay = o
+		return nil
+	})
+}
+
+func WithTransferGroupsConfig(gcfg config.Groups) TransferIngesterOption {
+	return transferIngesterOptionFunc(func(ti *TransferIngester) (err error) {
+		err = WithTransferDiscoveryDomain(gcfg.DiscoveryDomain).
+			applyToTransferIngester(ti)
+
+		if err == nil {
+			err = WithTransfers(gcfg.Transfers...).
+				applyToTransferIngester(ti)
+		}
+
+		if err == nil {
+			err = WithTransferValidators(NewIngestValidators(gcfg.Validation)...).
+				applyToTransferIngester(ti)
+		}
+
+		return
+	})
+}
+
+// TransferIngester pulls zones from primary DNS servers. The first ingest transfers each
+// zone in full with AXFR. Subsequent ingests use IXFR with the serial of the most recent
+// transfer, so that unchanged zones cost a single SOA. Whenever an IXFR fails, the zone
+// is transferred in full again.
+type TransferIngester struct {
+	ingestCore
+
+	// zones holds the state of each zone, which is updated by each transfer while
+	// the ingest lock is held
+	zones []*transferZone
+}
+
+// NewTransferIngester creates a TransferIngester from a set of options. At least one zone is required.
+func NewTransferIngester(opts ...TransferIngesterOption) (*TransferIngester, error) {
+	ti := new(TransferIngester)
+	for _, o := range opts {
+		if err := o.applyToTransferIngester(ti); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ti.zones) == 0 {
+		return nil, errors.New("at least one zone transfer is required")
+	}
+
+	ti.initialize()
+	return ti, nil
+}
+
+// HasZone tests if this TransferIngester pulls a zone. The zones never change after
+// construction, so the ingest lock is not needed.
+func (ti *TransferIngester) HasZone(zone string) bool {
+	zone = dnsutil.Canonical(zone)
+	return slices.ContainsFunc(ti.zones, func(tz *transferZone) bool {
+		return tz.zone == zone
+	})
+}
+
+// pull brings a single zone up to date with its primary.
+func (ti *TransferIngester) pull(ctx context.Context, tz *transferZone) (err error) {
+	m := tz.request()
+	transferType := dns.TypeToString[dns.RRToType(m.Question[0])]
+	ctx, span := ti.tracer.Start(ctx, "ingest.transfer", trace.WithAttributes(
+		attribute.String("dns.zone", tz.zone),
+		attribute.String("server.address", tz.primary),
+		attribute.String("hashy.transfer", transferType),
+	))
+
+	var (
+		rrs     []dns.RR
+		changed bool
+	)
+
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("hashy.rrs", len(rrs)),
+			attribute.Bool("hashy.changed", changed),
+		)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}()
+
+	incremental := tz.soa != nil
+	if rrs, err = tz.transfer(ctx, m); err == nil {
+		changed, err = tz.apply(rrs)
+	}
+
+	if incremental && err != nil && ctx.Err() == nil {
+		// differences that cannot be applied mean our copy of the zone can no longer be trusted,
+		// so any failed IXFR is followed by a full transfer
+		if !errors.Is(err, errFullTransferRequired) {
+			ti.logger.Warn("incremental zone transfer failed", zap.String("zone", tz.zone), zap.Error(err))
+		}
+
+		tz.soa = nil
+		transferType = dns.TypeToString[dns.TypeAXFR]
+		span.SetAttributes(attribute.String("hashy.transfer", transferType))
+		if rrs, err = tz.transfer(ctx, tz.request()); err == nil {
+			changed, err = tz.apply(rrs)
+		}
+	}
+
+	if err == nil {
+		ti.logger.Debug("zone transfer complete",
+			zap.String("zone", tz.zone),
+			zap.String("type", transferType),
+			zap.Uint32("serial", tz.soa.Serial),
+			zap.Bool("changed", changed),
+		)
+	}
+
+	return
+}
+
+// read brings every zone up to date with its primary, stopping at the first failed transfer.
+// The ingest lock must be held.
+func (ti *TransferIngester) read(ctx context.Context, checksummer medley.Hash[uint32]) (src SourceRRs, err error) {
+	for _, tz := range ti.zones {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		if err = ti.pull(ctx, tz); err != nil {
+			ti.logger.Error("zone transfer failed", zap.String("zone", tz.zone), zap.String("primary", tz.primary), zap.Error(err))
+			return
+		}
+
+		// a zone changes exactly when its serial does
+		checksummer.Write([]byte(tz.zone))
+		checksummer.Write(binary.BigEndian.AppendUint32(nil, tz.soa.Serial))
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("hashy.zones", len(ti.zones)))
+
+	for _, tz := range ti.zones {
+		src.RRs = append(src.RRs, tz.rrs...)
+	}
+
+	return
+}
+
+// ReadRRs brings every zone up to date with its primary, returning the RRs of all zones.
+// The serial of each zone is written to the checksummer.
+func (ti *TransferIngester) ReadRRs(ctx context.Context, checksummer medley.Hash[uint32]) (SourceRRs, error) {
+	defer ti.ingestLock.Unlock()
+	ti.ingestLock.Lock()
+
+	return ti.read(ctx, checksummer)
+}
+
+// Ingest brings every zone up to date with its primary, stopping at the first failed transfer.
+// An IngestEvent is only dispatched if either (a) this is the first Ingest, or (b) the serial
+// of any zone or the overlay changed.
+func (ti *TransferIngester) Ingest(ctx context.Context) {
+	ti.ingest(ctx, ti.read)
+}
@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"codeberg.org/miekg/dns"
+	"codeberg.org/miekg/dns/dnstest"
+	"github.com/xmidt-org/hashy/config"
+)
+
+const testTransferZone = "example.org."
+
+// newTestRR parses an RR in zone file format.
+func newTestRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.New(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rr
+}
+
+// newTestSOA creates the SOA of the test zone with a serial.
+func newTestSOA(t *testing.T, serial uint32) dns.RR {
+	return newTestRR(t, fmt.Sprintf("%s 3600 IN SOA ns.%s admin.%s %d 3600 600 86400 60", testTransferZone, testTransferZone, testTransferZone, serial))
+}
+
+// newTestEndpointRRs creates the SRV and A records of a single endpoint of the test group.
+func newTestEndpointRRs(t *testing.T, i int) []dns.RR {
+	return []dns.RR{
+		newTestRR(t, fmt.Sprintf("_test._tcp.%s 60 IN SRV 0 1 8080 e%d.%s", testTransferZone, i, testTransferZone)),
+		newTestRR(t, fmt.Sprintf("e%d.%s 60 IN A 192.0.2.%d", i, testTransferZone, i)),
+	}
+}
+
+// newTestTransferZone creates the AXFR answer for a zone whose test group has the given number of endpoints.
+func newTestTransferZone(t *testing.T, serial uint32, endpoints int) []dns.RR {
+	rrs := []dns.RR{
+		newTestSOA(t, serial),
+		newTestRR(t, fmt.Sprintf(`_hashy.%s 60 IN TXT "test _test._tcp.%s"`, testTransferZone, testTransferZone)),
+	}
+
+	for i := 1; i <= endpoints; i++ {
+		rrs = append(rrs, newTestEndpointRRs(t, i)...)
+	}
+
+	return append(rrs, newTestSOA(t, serial))
+}
+
+// testPrimary is a primary DNS server for the test zone. AXFR is answered with the zone,
+// while IXFR is answered with whatever differences a test sets.
+type testPrimary struct {
+	lock        sync.Mutex
+	zone        []dns.RR
+	differences []dns.RR
+	axfrs       int
+	ixfrs       int
+}
+
+func (tp *testPrimary) set(zone, differences []dns.RR) {
+	tp.lock.Lock()
+	tp.zone, tp.differences = zone, differences
+	tp.lock.Unlock()
+}
+
+func (tp *testPrimary) counts() (axfrs, ixfrs int) {
+	tp.lock.Lock()
+	defer tp.lock.Unlock()
+	return tp.axfrs, tp.ixfrs
+}
+
+func (tp *testPrimary) ServeDNS(_ context.Context, w dns.ResponseWriter, r *dns.Msg) {
+	r.Unpack()
+	w.Hijack()
+	defer w.Close()
+
+	tp.lock.Lock()
+	var answer []dns.RR
+	switch r.Question[0].(type) {
+	case *dns.AXFR:
+		tp.axfrs++
+		answer = tp.zone
+
+	case *dns.IXFR:
+		tp.ixfrs++
+		answer = tp.differences
+	}
+
+	tp.lock.Unlock()
+
+	// the whole answer goes in one message, and closing the connection ends the transfer
+	env := make(chan *dns.Envelope, 1)
+	env <- &dns.Envelope{Answer: answer}
+	close(env)
+	dns.NewClient().TransferOut(w, r, env)
+}
+
+// lastIngest is an IngestListener that keeps the most recent event.
+type lastIngest struct {
+	event IngestEvent
+}
+
+func (li *lastIngest) OnIngest(event IngestEvent) { li.event = event }
+
+func TestTransferIngesterFallback(t *testing.T) {
+	var primary testPrimary
+	cancel, address, err := dnstest.TCPServer("127.0.0.1:0", func(s *dns.Server) { s.Handler = &primary })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(cancel)
+
+	var last lastIngest
+	ti, err := NewTransferIngester(
+		WithTransferDiscoveryDomain("_hashy."+testTransferZone),
+		WithTransfers(config.Transfer{Zone: testTransferZone, Primary: address, Timeout: time.Second}),
+		WithTransferListeners(&last),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		zone        []dns.RR
+		differences []dns.RR
+		endpoints   int
+		axfrs       int
+		ixfrs       int
+	}{
+		{
+			name:      "initial",
+			zone:      newTestTransferZone(t, 1, 2),
+			endpoints: 2,
+			axfrs:     1,
+		},
+		{
+			name: "incremental",
+			zone: newTestTransferZone(t, 2, 3),
+			differences: append(
+				[]dns.RR{newTestSOA(t, 2), newTestSOA(t, 1), newTestSOA(t, 2)},
+				append(newTestEndpointRRs(t, 3), newTestSOA(t, 2))...,
+			),
+			endpoints: 3,
+			axfrs:     1,
+			ixfrs:     1,
+		},
+		{
+			name: "deletes a missing RR",
+			zone: newTestTransferZone(t, 3, 4),
+			differences: append(
+				append([]dns.RR{newTestSOA(t, 3), newTestSOA(t, 2)}, newTestEndpointRRs(t, 9)...),
+				newTestSOA(t, 3), newTestSOA(t, 3),
+			),
+			endpoints: 4,
+			axfrs:     2,
+			ixfrs:     2,
+		},
+		{
+			name: "ends with a deletion sequence",
+			zone: newTestTransferZone(t, 4, 1),
+			differences: append(
+				append([]dns.RR{newTestSOA(t, 4), newTestSOA(t, 3)}, newTestEndpointRRs(t, 2)...),
+				newTestSOA(t, 4), newTestSOA(t, 3), newTestSOA(t, 4),
+			),
+			endpoints: 1,
+			axfrs:     3,
+			ixfrs:     3,
+		},
+		{
+			name:        "full transfer required",
+			zone:        newTestTransferZone(t, 5, 2),
+			differences: []dns.RR{newTestSOA(t, 5)},
+			endpoints:   2,
+			axfrs:       4,
+			ixfrs:       4,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			primary.set(testCase.zone, testCase.differences)
+			ti.Ingest(context.Background())
+			if last.event.Err != nil {
+				t.Fatalf("unexpected ingest error: %s", last.event.Err)
+			}
+
+			g := last.event.Groups.Get("test")
+			if g == nil {
+				t.Fatal("expected the test group")
+			}
+
+			endpoints := 0
+			for range g.Endpoints() {
+				endpoints++
+			}
+
+			if endpoints != testCase.endpoints {
+				t.Errorf("expected %d endpoints, got %d", testCase.endpoints, endpoints)
+			}
+
+			if axfrs, ixfrs := primary.counts(); axfrs != testCase.axfrs || ixfrs != testCase.ixfrs {
+				t.Errorf("expected %d AXFRs and %d IXFRs, got %d and %d", testCase.axfrs, testCase.ixfrs, axfrs, ixfrs)
+			}
+		})
+	}
+}
+
+func TestTransferIngesterIncomplete(t *testing.T) {
+	var primary testPrimary
+	primary.set([]dns.RR{newTestSOA(t, 1)}, nil)
+	cancel, address, err := dnstest.TCPServer("127.0.0.1:0", func(s *dns.Server) { s.Handler = &primary })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(cancel)
+
+	var last lastIngest
+	ti, err := NewTransferIngester(
+		WithTransferDiscoveryDomain("_hashy."+testTransferZone),
+		WithTransfers(config.Transfer{Zone: testTransferZone, Primary: address, Timeout: time.Second}),
+		WithTransferListeners(&last),
+	)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ti.Ingest(context.Background())
+	if last.event.Err == nil {
+		t.Fatal("expected an error for a transfer with only a SOA")
+	}
+
+	if axfrs, ixfrs := primary.counts(); axfrs != 1 || ixfrs != 0 {
+		t.Errorf("expected 1 AXFR and no IXFRs, got %d and %d", axfrs, ixfrs)
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/xmidt-org/hashy/config"
+)
+
+// ErrIngestRejected indicates that an ingest produced groups that failed validation.
+// Rejected groups are never used, so the current groups stay in place.
+var ErrIngestRejected = errors.New("ingest rejected")
+
+// IngestValidator checks the groups produced by an ingest before they are used.
+type IngestValidator interface {
+	// Validate checks next, the groups an ingest produced, against current, the groups
+	// in use. The current groups are nil until some ingest has been accepted.
+	// A non-nil error rejects next.
+	Validate(current, next *Groups) error
+}
+
+// IngestValidatorFunc is a function type that implements IngestValidator.
+type IngestValidatorFunc func(current, next *Groups) error
+
+func (f IngestValidatorFunc) Validate(current, next *Groups) error { return f(current, next) }
+
+// IngestValidators is a chain of IngestValidator instances. Every validator runs,
+// so that each problem with an ingest is reported.
+type IngestValidators []IngestValidator
+
+func (ivs IngestValidators) Validate(current, next *Groups) (err error) {
+	for _, v := range ivs {
+		err = errors.Join(err, v.Validate(current, next))
+	}
+
+	return
+}
+
+// NewIngestValidators creates the built-in validators enabled by the given configuration.
+func NewIngestValidators(vcfg config.Validation) (ivs IngestValidators) {
+	if vcfg.RequireEndpoints {
+		ivs = append(ivs, RequireEndpoints())
+	}
+
+	if vcfg.RequireAddresses {
+		ivs = append(ivs, RequireAddresses())
+	}
+
+	if vcfg.MaxRemovedPercent > 0.0 {
+		ivs = append(ivs, MaxRemoved(vcfg.MaxRemovedPercent))
+	}
+
+	return
+}
+
+// RequireEndpoints rejects groups that have no endpoints.
+func RequireEndpoints() IngestValidator {
+	return IngestValidatorFunc(func(_, next *Groups) (err error) {
+		for g := range next.All() {
+			if g.Len() == 0 {
+				err = errors.Join(err, fmt.Errorf("group %s has no endpoints", g.Name()))
+			}
+		}
+
+		return
+	})
+}
+
+// RequireAddresses rejects groups with SRV targets that have no A or AAAA records.
+func RequireAddresses() IngestValidator {
+	return IngestValidatorFunc(func(_, next *Groups) (err error) {
+		for g := range next.All() {
+			for target := range g.Unresolved() {
+				err = errors.Join(err, fmt.Errorf("group %s has an SRV target without addresses: %s", g.Name(), target))
+			}
+		}
+
+		return
+	})
+}
+
+// MaxRemoved rejects updates that remove more than a percentage of the current endpoints.
+// An endpoint counts once for each group it belongs to, so removing a whole group removes
+// each of its endpoints. The first groups accepted are never rejected by this validator.
+func MaxRemoved(percent float64) IngestValidator {
+	return IngestValidatorFunc(func(current, next *Groups) error {
+		if current == nil {
+			return nil
+		}
+
+		total, removed := 0, 0
+		for g := range current.All() {
+			remaining := make(map[string]bool)
+			if ng := next.Get(g.Name()); ng != nil {
+				for e := range ng.Endpoints() {
+					remaining[e.OriginalName()] = true
+				}
+			}
+
+			for e := range g.Endpoints() {
+				total++
+				if !remaining[e.OriginalName()] {
+					removed++
+				}
+			}
+		}
+
+		if total > 0 && float64(removed)*100.0 > percent*float64(total) {
+			return fmt.Errorf("%d of %d endpoints removed, which exceeds %g%%", removed, total, percent)
+		}
+
+		return nil
+	})
+}
+
+// ingestGate runs the validators over each new set of groups, tracking the groups most
+// recently accepted. Ingesters use an ingestGate to decide whether to dispatch new groups.
+type ingestGate struct {
+	lock       sync.Mutex
+	validators IngestValidators
+	accepted   *Groups
+}
+
+// seed sets the groups that the next groups are validated against, such as the groups restored
+// from a snapshot. Once any groups have been admitted, seeding has no effect.
+func (ig *ingestGate) seed(gps *Groups) {
+	defer ig.lock.Unlock()
+	ig.lock.Lock()
+
+	if ig.accepted == nil {
+		ig.accepted = gps
+	}
+}
+
+// admit validates new groups against the groups most recently admitted. If the new groups
+// pass, they become the groups that later groups are validated against. Otherwise, the
+// returned error wraps ErrIngestRejected.
+func (ig *ingestGate) admit(next *Groups) (err error) {
+	defer ig.lock.Unlock()
+	ig.lock.Lock()
+
+	if err = ig.validators.Validate(ig.accepted, next); err != nil {
+		err = fmt.Errorf("%w: %w", ErrIngestRejected, err)
+	} else {
+		ig.accepted = next
+	}
+
+	return
+}
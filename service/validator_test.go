@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/xmidt-org/hashy/config"
+)
+
+// newTestEndpointGroups creates a group named "test" with a number of equally weighted endpoints.
+func newTestEndpointGroups(t *testing.T, endpoints int) *Groups {
+	return newWeightedGroups(t, AlgorithmRing, slices.Repeat([]uint16{1}, endpoints)...)
+}
+
+func TestMaxRemoved(t *testing.T) {
+	current := newTestEndpointGroups(t, 10)
+	testCases := []struct {
+		name   string
+		next   *Groups
+		reject bool
+	}{
+		{name: "no change", next: newTestEndpointGroups(t, 10)},
+		{name: "added", next: newTestEndpointGroups(t, 12)},
+		{name: "at the threshold", next: newTestEndpointGroups(t, 8)},
+		{name: "just past the threshold", next: newTestEndpointGroups(t, 7), reject: true},
+		{name: "group removed", next: newTestGroups(t), reject: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := MaxRemoved(20.0).Validate(current, testCase.next)
+			if testCase.reject && err == nil {
+				t.Error("expected the groups to be rejected")
+			} else if !testCase.reject && err != nil {
+				t.Errorf("unexpected rejection: %s", err)
+			}
+		})
+	}
+
+	if err := MaxRemoved(20.0).Validate(nil, newTestGroups(t)); err != nil {
+		t.Errorf("the first groups must never be rejected: %s", err)
+	}
+}
+
+func TestIngestGate(t *testing.T) {
+	ig := ingestGate{validators: NewIngestValidators(config.Validation{MaxRemovedPercent: 20.0})}
+	first := newTestEndpointGroups(t, 10)
+	if err := ig.admit(first); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ig.admit(newTestEndpointGroups(t, 7))
+	if !errors.Is(err, ErrIngestRejected) {
+		t.Fatalf("expected %v, got %v", ErrIngestRejected, err)
+	}
+
+	if ig.accepted != first {
+		t.Fatal("rejected groups must not replace the accepted groups")
+	}
+
+	// later groups are still validated against the groups last accepted
+	if err := ig.admit(newTestEndpointGroups(t, 8)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// zoneGlobs is a set of patterns for zone files. Patterns use doublestar syntax, where **
+// matches any number of directories. A pattern that begins with ! excludes any file it
+// matches, even if other patterns include that file. Exclusions are matched against the
+// same paths that inclusions produce, so `!**/*.bak` excludes backups anywhere.
+type zoneGlobs struct {
+	includes []string
+	excludes []string
+}
+
+// newZoneGlobs parses a set of patterns.
+func newZoneGlobs(patterns ...string) (zg zoneGlobs, err error) {
+	for _, p := range patterns {
+		exclude := strings.HasPrefix(p, "!")
+		if exclude {
+			p = p[1:]
+		}
+
+		p = filepath.Clean(p)
+		if !doublestar.ValidatePathPattern(p) {
+			err = errors.Join(err, fmt.Errorf("invalid zone file pattern: %s", p))
+		} else if exclude {
+			zg.excludes = append(zg.excludes, p)
+		} else {
+			zg.includes = append(zg.includes, p)
+		}
+	}
+
+	return
+}
+
+// excluded returns true if any exclusion matches a path.
+func (zg zoneGlobs) excluded(path string) bool {
+	return slices.ContainsFunc(zg.excludes, func(p string) bool {
+		return doublestar.PathMatchUnvalidated(p, path)
+	})
+}
+
+// matches returns true if a path is included by some pattern and not excluded.
+func (zg zoneGlobs) matches(path string) bool {
+	return !zg.excluded(path) && slices.ContainsFunc(zg.includes, func(p string) bool {
+		return doublestar.PathMatchUnvalidated(p, path)
+	})
+}
+
+// globBase splits a pattern into the directory before its first meta character and
+// the greatest depth of directories below that base that the pattern can match.
+// A depth of -1 means any depth, which is the case for patterns with **.
+func globBase(pattern string) (base string, depth int) {
+	var rest string
+	base, rest = doublestar.SplitPattern(filepath.ToSlash(pattern))
+	base = filepath.FromSlash(base)
+	if strings.Contains(rest, "**") {
+		depth = -1
+	} else {
+		depth = strings.Count(rest, "/")
+	}
+
+	return
+}
+
+// walkPattern visits each file below a pattern's base that the pattern might match. Symbolic
+// links are followed, but a directory is never entered from within itself, which protects
+// against symbolic link loops.
+func walkPattern(pattern string, visit func(path string)) error {
+	base, depth := globBase(pattern)
+	info, err := os.Stat(base)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// just like filepath.Glob, a missing directory simply has no matches
+		return nil
+
+	case err != nil:
+		return err
+
+	case !info.IsDir():
+		visit(base)
+		return nil
+	}
+
+	var walk func(dir string, level int, ancestors []fs.FileInfo) error
+	walk = func(dir string, level int, ancestors []fs.FileInfo) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil && level == 0 {
+			return err
+		}
+
+		// just like filepath.Glob, unreadable subdirectories are skipped
+
+		for _, e := range entries {
+			path := filepath.Join(dir, e.Name())
+			info, err := os.Stat(path) // follows symbolic links
+			switch {
+			case err != nil:
+				// dangling symbolic links and files removed during the walk are skipped
+
+			case !info.IsDir():
+				visit(path)
+
+			case depth >= 0 && level >= depth:
+				// the pattern cannot match anything this deep
+
+			case slices.ContainsFunc(ancestors, func(a fs.FileInfo) bool { return os.SameFile(a, info) }):
+				// a symbolic link loop
+
+			default:
+				if err := walk(path, level+1, append(ancestors, info)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return walk(base, 0, []fs.FileInfo{info})
+}
+
+// files is a sequence of the files these patterns match. Each pattern's matches are sorted
+// lexicographically for a consistent processing order. A file matched by several patterns,
+// including through symbolic links, is only produced for the first. If any error occurs, the
+// yield function is called with an empty string and the error.
+func (zg zoneGlobs) files(yield func(string, error) bool) {
+	seen := make(map[string]bool)
+	for _, pattern := range zg.includes {
+		var matches []string
+		err := walkPattern(pattern, func(path string) {
+			if doublestar.PathMatchUnvalidated(pattern, path) && !zg.excluded(path) {
+				matches = append(matches, path)
+			}
+		})
+
+		if err != nil {
+			yield("", err)
+			return
+		}
+
+		slices.Sort(matches)
+		for _, path := range matches {
+			key, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				key = path
+			}
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			if !yield(path, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2026 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xmidt-org/hashy/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+)
+
+const (
+	// ServiceName is the name hashy reports in its traces.
+	ServiceName = "hashy"
+)
+
+// NewTracerProvider creates a TracerProvider that exports spans over OTLP/HTTP. The returned
+// provider must be shut down to flush any remaining spans.
+func NewTracerProvider(tcfg config.Tracing) (*sdktrace.TracerProvider, error) {
+	if tcfg.SampleRatio < 0.0 || tcfg.SampleRatio > 1.0 {
+		return nil, fmt.Errorf("invalid sample ratio %g: must be between 0.0 and 1.0", tcfg.SampleRatio)
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(tcfg.Endpoint),
+	}
+
+	if len(tcfg.URLPath) > 0 {
+		opts = append(opts, otlptracehttp.WithURLPath(tcfg.URLPath))
+	}
+
+	if tcfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if len(tcfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(tcfg.Headers))
+	}
+
+	// creating the exporter doesn't connect, so this won't block startup
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if tcfg.SampleRatio > 0.0 {
+		sampler = sdktrace.TraceIDRatioBased(tcfg.SampleRatio)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(
+			resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+		),
+	), nil
+}
+
+// Provide creates the trace.TracerProvider used throughout hashy. When tracing is
+// not configured, the provider is a no-op.
+func Provide() fx.Option {
+	return fx.Provide(
+		func(tcfg config.Tracing, lc fx.Lifecycle) (trace.TracerProvider, error) {
+			if len(tcfg.Endpoint) == 0 {
+				return noop.NewTracerProvider(), nil
+			}
+
+			tp, err := NewTracerProvider(tcfg)
+			if err == nil {
+				lc.Append(fx.StopHook(tp.Shutdown))
+			}
+
+			return tp, err
+		},
+	)
+}